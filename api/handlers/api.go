@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
@@ -24,6 +27,9 @@ type App struct {
 	DB       databases.CollectionHelper
 	Config   config.Config
 	dbHelper databases.DatabaseHelper
+	blobs    databases.BlobStore
+	slas     SLAMonitor
+	releases Disclosures
 }
 
 // New creates a new mux router and all the routes
@@ -31,13 +37,43 @@ func (a *App) New() *mux.Router {
 
 	r := mux.NewRouter()
 	r.Use(api.MuxCORS)
+	r.Use(api.Deadline(a.Config))
 
 	// create database handlers like this
 	authService := auth.NewAuthServiceFromEnv()
-	users := User{DB: databases.NewUserDatabase(a.dbHelper), Auth: authService}
-	projects := Project{DB: databases.NewProjectDatabase(a.dbHelper)}
-	reports := Report{DB: databases.NewReportDatabase(a.dbHelper)}
-	comments := Comment{DB: databases.NewCommentDatabase(a.dbHelper)}
+	userDB := databases.NewUserDatabase(a.dbHelper)
+	projectDB := databases.NewProjectDatabase(a.dbHelper)
+	reportDB := databases.NewReportDatabase(a.dbHelper)
+	members := Membership{DB: databases.NewMembershipDatabase(a.dbHelper), Users: userDB, Projects: projectDB, Reports: reportDB}
+	subscriptions := Subscription{DB: databases.NewSubscriptionDatabase(a.dbHelper), Reports: reportDB, Projects: projectDB, Members: members}
+	votes := Vote{DB: databases.NewVoteDatabase(a.dbHelper), Reports: reportDB, Users: userDB, Members: members}
+	bountyDB := databases.NewBountyDatabase(a.dbHelper)
+	reputation := Reputation{DB: databases.NewReputationDatabase(a.dbHelper), Reports: reportDB, Users: userDB, Bounties: bountyDB, Members: members}
+	bounties := Bounty{
+		DB:         bountyDB,
+		Ledger:     databases.NewLedgerDatabase(a.dbHelper),
+		Reports:    reportDB,
+		Projects:   projectDB,
+		Reputation: reputation,
+	}
+	events := Event{DB: databases.NewEventDatabase(a.dbHelper), Watchers: subscriptions}
+	users := User{DB: userDB, Auth: authService, Members: members, Watchers: subscriptions, Votes: votes, Reputation: reputation}
+	invitations := Invitation{DB: databases.NewInvitationDatabase(a.dbHelper), Members: members, TTL: a.Config.InvitationTTL}
+	templates := Templates{DB: databases.NewTemplateDatabase(a.dbHelper), Projects: projectDB}
+	commentDB := databases.NewCommentDatabase(a.dbHelper)
+	projects := Project{DB: projectDB, Members: members, Watchers: subscriptions, Invitations: invitations.DB, Templates: templates, Comments: commentDB}
+	attachments := Attachment{
+		DB:       databases.NewAttachmentDatabase(a.dbHelper),
+		Blobs:    a.blobs,
+		Comments: commentDB,
+		Members:  members,
+		MaxSize:  a.Config.MaxAttachmentSize,
+		Quota:    a.Config.ProjectQuota,
+	}
+	a.releases = Disclosures{Reports: reportDB, Events: events}
+	reports := Report{DB: reportDB, Members: members, Attachments: attachments, Watchers: subscriptions, Votes: votes, Reputation: reputation, Disclosures: a.releases, Templates: templates}
+	comments := Comment{DB: commentDB, Attachments: attachments, Watchers: subscriptions}
+	a.slas = SLAMonitor{Reports: reportDB, Events: events}
 
 	// healthcheck
 	r.HandleFunc("/health", healthCheckHandler)
@@ -50,22 +86,107 @@ func (a *App) New() *mux.Router {
 	apiCreate.Handle("/user/update/{user_id}", api.Middleware(a.Config, http.HandlerFunc(users.UpdateUserHandler))).Methods("PATCH")
 	apiCreate.Handle("/user/delete/{user_id}", api.Middleware(a.Config, http.HandlerFunc(users.DeleteUserByIdHandler))).Methods("DELETE")
 	apiCreate.Handle("/user/login", http.HandlerFunc(users.LoginHandler)).Methods("POST")
+	apiCreate.Handle("/user/{user_id}/subscriptions", api.Middleware(a.Config, http.HandlerFunc(subscriptions.UserSubscriptionsHandler))).Methods("GET")
+	apiCreate.Handle("/user/{user_id}/events", api.Middleware(a.Config, http.HandlerFunc(events.UserEventsHandler))).Methods("GET")
+	apiCreate.Handle("/user/{user_id}/rewards", api.Middleware(a.Config, http.HandlerFunc(bounties.UserRewardsHandler))).Methods("GET")
+	apiCreate.Handle("/user/{user_id}/reputation", api.Middleware(a.Config, http.HandlerFunc(reputation.UserReputationHandler))).Methods("GET")
+	apiCreate.Handle("/user/{user_id}/reputation", api.Middleware(a.Config, http.HandlerFunc(reputation.RecalculateReputationHandler))).Methods("POST")
+	apiCreate.Handle("/user/{user_id}/projects", api.Middleware(a.Config, http.HandlerFunc(members.UserProjectsHandler))).Methods("GET")
+	apiCreate.Handle("/user/{user_id}/assigned", api.Middleware(a.Config, http.HandlerFunc(reports.AssignedReportsHandler))).Methods("GET")
+
+	apiCreate.Handle("/leaderboard", api.Middleware(a.Config, http.HandlerFunc(reputation.LeaderboardHandler))).Methods("GET")
 
+	apiCreate.Handle("/report/search", api.Middleware(a.Config, http.HandlerFunc(reports.SearchReportsHandler))).Methods("GET")
+	apiCreate.Handle("/report/duplicates", api.Middleware(a.Config, http.HandlerFunc(reports.CheckDuplicatesHandler))).Methods("POST")
 	apiCreate.Handle("/report/{report_id}", api.Middleware(a.Config, http.HandlerFunc(reports.ReportByObjectIDHandler))).Methods("GET")
 	apiCreate.Handle("/report/create", api.Middleware(a.Config, http.HandlerFunc(reports.NewReportHandler))).Methods("POST")
 	apiCreate.Handle("/report/update/{report_id}", api.Middleware(a.Config, http.HandlerFunc(reports.UpdateReportHanlder))).Methods("PATCH")
 	apiCreate.Handle("/report/delete/{report_id}", api.Middleware(a.Config, http.HandlerFunc(reports.DeleteReportByIdHandler))).Methods("DELETE")
+	apiCreate.Handle("/report/{report_id}/transition", api.Middleware(a.Config, http.HandlerFunc(reports.TransitionReportHandler))).Methods("POST")
+	apiCreate.Handle("/report/{report_id}/merge", api.Middleware(a.Config, http.HandlerFunc(reports.MergeReportHandler))).Methods("POST")
+	apiCreate.Handle("/report/{report_id}/links", api.Middleware(a.Config, http.HandlerFunc(reports.ReportLinksHandler))).Methods("GET")
+	apiCreate.Handle("/report/{report_id}/links", api.Middleware(a.Config, http.HandlerFunc(reports.AddReportLinkHandler))).Methods("POST")
+	apiCreate.Handle("/report/{report_id}/links/{linked_id}", api.Middleware(a.Config, http.HandlerFunc(reports.RemoveReportLinkHandler))).Methods("DELETE")
+	apiCreate.Handle("/report/{report_id}/watch", api.Middleware(a.Config, http.HandlerFunc(subscriptions.WatchReportHandler))).Methods("POST")
+	apiCreate.Handle("/report/{report_id}/watch", api.Middleware(a.Config, http.HandlerFunc(subscriptions.UnwatchReportHandler))).Methods("DELETE")
+	apiCreate.Handle("/report/{report_id}/watchers", api.Middleware(a.Config, http.HandlerFunc(subscriptions.ReportWatchersHandler))).Methods("GET")
+	apiCreate.Handle("/report/{report_id}/vote", api.Middleware(a.Config, http.HandlerFunc(votes.VoteReportHandler))).Methods("POST")
+	apiCreate.Handle("/report/{report_id}/vote", api.Middleware(a.Config, http.HandlerFunc(votes.UnvoteReportHandler))).Methods("DELETE")
+	apiCreate.Handle("/report/{report_id}/voters", api.Middleware(a.Config, http.HandlerFunc(votes.ReportVotersHandler))).Methods("GET")
+	apiCreate.Handle("/report/{report_id}/bounty", api.Middleware(a.Config, http.HandlerFunc(bounties.ReportBountyHandler))).Methods("GET")
+	apiCreate.Handle("/report/{report_id}/bounty", api.Middleware(a.Config, http.HandlerFunc(bounties.AwardBountyHandler))).Methods("POST")
+	apiCreate.Handle("/report/{report_id}/disclosure", api.Middleware(a.Config, http.HandlerFunc(reports.ScheduleDisclosureHandler))).Methods("POST")
+	apiCreate.Handle("/report/{report_id}/disclosure", api.Middleware(a.Config, http.HandlerFunc(reports.CancelDisclosureHandler))).Methods("DELETE")
+	apiCreate.Handle("/report/{report_id}/triage", api.Middleware(a.Config, http.HandlerFunc(reports.TriageReportHandler))).Methods("POST")
+	apiCreate.Handle("/report/{report_id}/assignees", api.Middleware(a.Config, http.HandlerFunc(reports.AssignReportHandler))).Methods("POST")
+	apiCreate.Handle("/report/{report_id}/assignees/{user_id}", api.Middleware(a.Config, http.HandlerFunc(reports.UnassignReportHandler))).Methods("DELETE")
+	apiCreate.Handle("/report/{report_id}/labels", api.Middleware(a.Config, http.HandlerFunc(reports.AddLabelsHandler))).Methods("POST")
+	apiCreate.Handle("/report/{report_id}/labels/{name}", api.Middleware(a.Config, http.HandlerFunc(reports.RemoveLabelHandler))).Methods("DELETE")
+	apiCreate.Handle("/report/{report_id}/components", api.Middleware(a.Config, http.HandlerFunc(reports.AddComponentsHandler))).Methods("POST")
+	apiCreate.Handle("/report/{report_id}/components/{name}", api.Middleware(a.Config, http.HandlerFunc(reports.RemoveComponentHandler))).Methods("DELETE")
+	apiCreate.Handle("/report/{report_id}/attachments", api.Middleware(a.Config, http.HandlerFunc(attachments.ReportAttachmentsHandler))).Methods("GET")
+	apiCreate.Handle("/report/{report_id}/attachments", api.Middleware(a.Config, http.HandlerFunc(attachments.UploadReportAttachmentHandler))).Methods("POST")
 
 	apiCreate.Handle("/project/{project_id}", api.Middleware(a.Config, http.HandlerFunc(projects.ProjectByObjectIDHandler))).Methods("GET")
 	apiCreate.Handle("/project/create", api.Middleware(a.Config, http.HandlerFunc(projects.NewProjectHandler))).Methods("POST")
 	apiCreate.Handle("/project/update/{project_id}", api.Middleware(a.Config, http.HandlerFunc(projects.UpdateProjectHandler))).Methods("PATCH")
 	apiCreate.Handle("/project/delete/{project_id}", api.Middleware(a.Config, http.HandlerFunc(projects.DeleteProjectByIdHandler))).Methods("DELETE")
+	apiCreate.Handle("/project/{project_id}/archive", api.Middleware(a.Config, http.HandlerFunc(members.ArchiveProjectHandler))).Methods("POST")
+	apiCreate.Handle("/project/{project_id}/archive", api.Middleware(a.Config, http.HandlerFunc(members.UnarchiveProjectHandler))).Methods("DELETE")
+	apiCreate.Handle("/project/{project_id}/visibility", api.Middleware(a.Config, http.HandlerFunc(projects.UpdateVisibilityHandler))).Methods("PUT")
+	apiCreate.Handle("/project/{project_id}/template", api.Middleware(a.Config, http.HandlerFunc(projects.TemplateHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/templates", api.Middleware(a.Config, http.HandlerFunc(projects.TemplateVersionsHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/templates/diff", api.Middleware(a.Config, http.HandlerFunc(projects.TemplateDiffHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/templates/{version:[0-9]+}", api.Middleware(a.Config, http.HandlerFunc(projects.TemplateVersionHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/workflow", api.Middleware(a.Config, http.HandlerFunc(projects.WorkflowHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/workflow", api.Middleware(a.Config, http.HandlerFunc(projects.UpdateWorkflowHandler))).Methods("PUT")
+	apiCreate.Handle("/project/{project_id}/autoassign", api.Middleware(a.Config, http.HandlerFunc(projects.UpdateAutoAssignHandler))).Methods("PUT")
+	apiCreate.Handle("/project/{project_id}/autoassign", api.Middleware(a.Config, http.HandlerFunc(projects.DeleteAutoAssignHandler))).Methods("DELETE")
+	apiCreate.Handle("/project/{project_id}/labels", api.Middleware(a.Config, http.HandlerFunc(projects.UpdateLabelsHandler))).Methods("PUT")
+	apiCreate.Handle("/project/{project_id}/components", api.Middleware(a.Config, http.HandlerFunc(projects.UpdateComponentsHandler))).Methods("PUT")
+	apiCreate.Handle("/project/{project_id}/fields", api.Middleware(a.Config, http.HandlerFunc(projects.UpdateCustomFieldsHandler))).Methods("PUT")
+	apiCreate.Handle("/project/{project_id}/reports", api.Middleware(a.Config, http.HandlerFunc(reports.ProjectReportsHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/milestones", api.Middleware(a.Config, http.HandlerFunc(projects.UpdateMilestonesHandler))).Methods("PUT")
+	apiCreate.Handle("/project/{project_id}/milestones/{name}/progress", api.Middleware(a.Config, http.HandlerFunc(projects.MilestoneProgressHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/sla", api.Middleware(a.Config, http.HandlerFunc(projects.UpdateSLAHandler))).Methods("PUT")
+	apiCreate.Handle("/project/{project_id}/sla", api.Middleware(a.Config, http.HandlerFunc(projects.DeleteSLAHandler))).Methods("DELETE")
+	apiCreate.Handle("/project/{project_id}/rewards", api.Middleware(a.Config, http.HandlerFunc(bounties.ProjectRewardsHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/rewards", api.Middleware(a.Config, http.HandlerFunc(projects.UpdateRewardsHandler))).Methods("PUT")
+	apiCreate.Handle("/project/{project_id}/rewards", api.Middleware(a.Config, http.HandlerFunc(projects.DeleteRewardsHandler))).Methods("DELETE")
+	apiCreate.Handle("/project/{project_id}/leaderboard", api.Middleware(a.Config, http.HandlerFunc(reputation.ProjectLeaderboardHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/bounties", api.Middleware(a.Config, http.HandlerFunc(bounties.ProjectBountiesHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/ledger", api.Middleware(a.Config, http.HandlerFunc(bounties.ProjectLedgerHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/watch", api.Middleware(a.Config, http.HandlerFunc(subscriptions.WatchProjectHandler))).Methods("POST")
+	apiCreate.Handle("/project/{project_id}/watch", api.Middleware(a.Config, http.HandlerFunc(subscriptions.UnwatchProjectHandler))).Methods("DELETE")
+	apiCreate.Handle("/project/{project_id}/watchers", api.Middleware(a.Config, http.HandlerFunc(subscriptions.ProjectWatchersHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/members", api.Middleware(a.Config, http.HandlerFunc(members.ProjectMembersHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/members", api.Middleware(a.Config, http.HandlerFunc(members.AddMemberHandler))).Methods("POST")
+	apiCreate.Handle("/project/{project_id}/members/{user_id}", api.Middleware(a.Config, http.HandlerFunc(members.UpdateMemberHandler))).Methods("PATCH")
+	apiCreate.Handle("/project/{project_id}/members/{user_id}", api.Middleware(a.Config, http.HandlerFunc(members.RemoveMemberHandler))).Methods("DELETE")
+	apiCreate.Handle("/project/{project_id}/admins/{user_id}", api.Middleware(a.Config, http.HandlerFunc(members.AddAdminHandler))).Methods("PUT")
+	apiCreate.Handle("/project/{project_id}/admins/{user_id}", api.Middleware(a.Config, http.HandlerFunc(members.RemoveAdminHandler))).Methods("DELETE")
+	apiCreate.Handle("/project/{project_id}/transfer", api.Middleware(a.Config, http.HandlerFunc(members.TransferOwnershipHandler))).Methods("POST")
+	apiCreate.Handle("/project/{project_id}/transfer", api.Middleware(a.Config, http.HandlerFunc(members.CancelTransferHandler))).Methods("DELETE")
+	apiCreate.Handle("/project/{project_id}/transfer/accept", api.Middleware(a.Config, http.HandlerFunc(members.AcceptTransferHandler))).Methods("POST")
+	apiCreate.Handle("/project/{project_id}/transfer/decline", api.Middleware(a.Config, http.HandlerFunc(members.DeclineTransferHandler))).Methods("POST")
+	apiCreate.Handle("/project/{project_id}/invitations", api.Middleware(a.Config, http.HandlerFunc(invitations.ProjectInvitationsHandler))).Methods("GET")
+	apiCreate.Handle("/project/{project_id}/invitations", api.Middleware(a.Config, http.HandlerFunc(invitations.InviteMemberHandler))).Methods("POST")
+	apiCreate.Handle("/project/{project_id}/invitations/{invitation_id}", api.Middleware(a.Config, http.HandlerFunc(invitations.RevokeInvitationHandler))).Methods("DELETE")
+
+	apiCreate.Handle("/invitation/{token}/accept", api.Middleware(a.Config, http.HandlerFunc(invitations.AcceptInvitationHandler))).Methods("POST")
+	apiCreate.Handle("/invitation/{token}/decline", api.Middleware(a.Config, http.HandlerFunc(invitations.DeclineInvitationHandler))).Methods("POST")
 
 	apiCreate.Handle("/comment/{comment_id}", api.Middleware(a.Config, http.HandlerFunc(comments.CommentByObjectIDHandler))).Methods("GET")
 	apiCreate.Handle("/comment/report/{report_id}", api.Middleware(a.Config, http.HandlerFunc(comments.CommentsByReportIDHandler))).Methods("GET")
 	apiCreate.Handle("/comment/create", api.Middleware(a.Config, http.HandlerFunc(comments.NewCommentHandler))).Methods("POST")
 	apiCreate.Handle("/comment/update/{comment_id}", api.Middleware(a.Config, http.HandlerFunc(comments.UpdateCommentHandler))).Methods("PATCH")
 	apiCreate.Handle("/comment/delete/{comment_id}", api.Middleware(a.Config, http.HandlerFunc(comments.DeleteCommentByIdHandler))).Methods("DELETE")
+	apiCreate.Handle("/comment/{comment_id}/attachments", api.Middleware(a.Config, http.HandlerFunc(attachments.UploadCommentAttachmentHandler))).Methods("POST")
+
+	apiCreate.Handle("/bounty/{bounty_id}/pay", api.Middleware(a.Config, http.HandlerFunc(bounties.PayBountyHandler))).Methods("POST")
+
+	apiCreate.Handle("/attachment/{attachment_id}", api.Middleware(a.Config, http.HandlerFunc(attachments.DownloadAttachmentHandler))).Methods("GET")
+	apiCreate.Handle("/attachment/{attachment_id}", api.Middleware(a.Config, http.HandlerFunc(attachments.DeleteAttachmentHandler))).Methods("DELETE")
 
 	return r
 }
@@ -87,12 +208,53 @@ func (a *App) Initialize() error {
 	}
 	zap.S().Info("DeviceBookingAPI has connected to the database")
 
+	err = a.dbHelper.EnsureIndexes(context.Background())
+	if err != nil {
+		zap.S().With(err).Error("failed to create database indexes")
+		return err
+	}
+
+	a.blobs, err = databases.NewBlobStore(&a.Config, a.dbHelper)
+	if err != nil {
+		zap.S().With(err).Error("failed to create blob store")
+		return err
+	}
+
 	// initialize api router
 	a.initializeRoutes()
+
+	// flag reports that miss their SLA deadlines and disclose reports on their
+	// publish date in the background
+	go every(context.Background(), a.Config.SLACheckInterval, "flag SLA breaches", a.slas.check)
+	go every(context.Background(), a.Config.DisclosureCheckInterval, "disclose reports", a.releases.check)
 	return nil
 
 }
 
+// every runs a background task each interval until ctx is done. The task
+// returns how many things it changed, which is logged when there are any
+func every(ctx context.Context, interval time.Duration, task string, run func(context.Context, time.Time) (int, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			runCtx, cancel := context.WithTimeout(ctx, interval)
+			changed, err := run(runCtx, now.UTC())
+			cancel()
+
+			if err != nil {
+				zap.S().With(err).Errorf("failed to %s", task)
+			} else if changed > 0 {
+				zap.S().Infow("ran background task", "task", task, "count", changed)
+			}
+		}
+	}
+}
+
 func (a *App) initializeRoutes() {
 	a.Router = a.New()
 }
@@ -105,3 +267,11 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	})
 	_, _ = io.WriteString(w, string(b))
 }
+
+// pageFromRequest reads the page and pageSize query parameters, missing or
+// invalid values fall back to the repository defaults
+func pageFromRequest(r *http.Request) databases.Page {
+	number, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+	size, _ := strconv.ParseInt(r.URL.Query().Get("pageSize"), 10, 64)
+	return databases.Page{Number: number, Size: size}
+}
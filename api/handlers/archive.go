@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// archivedMessage is the error returned for writes to an archived project
+const archivedMessage = "the project is archived and read only until its owner unarchives it"
+
+// readOnly loads a project and reports whether it is archived. When it is, or
+// it can't be loaded, the error response is written and true is returned
+func (membership Membership) readOnly(w http.ResponseWriter, r *http.Request, projectID string) bool {
+	pID, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return true
+	}
+
+	project, err := membership.Projects.FindByID(r.Context(), pID)
+	if err != nil {
+		config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, err)
+		return true
+	}
+
+	return archived(w, project)
+}
+
+// archived reports whether a project is archived, writing the error response when it is
+func archived(w http.ResponseWriter, project *models.Project) bool {
+	if project.Archived {
+		config.ErrorStatus(archivedMessage, http.StatusConflict, w, nil)
+		return true
+	}
+	return false
+}
+
+// ArchiveProjectHandler archives a project, only its owner can archive it.
+// Archived projects stay readable but their settings, reports, comments and
+// attachments can't change, and they are left out of project listings
+func (membership Membership) ArchiveProjectHandler(w http.ResponseWriter, r *http.Request) {
+	membership.archive(w, r, true)
+}
+
+// UnarchiveProjectHandler makes an archived project writable again, only its owner can unarchive it
+func (membership Membership) UnarchiveProjectHandler(w http.ResponseWriter, r *http.Request) {
+	membership.archive(w, r, false)
+}
+
+// archive archives or unarchives a project and records it in the project history
+func (membership Membership) archive(w http.ResponseWriter, r *http.Request, archived bool) {
+	ctx := r.Context()
+
+	project := projectAdmin(ctx, w, r, membership.Projects, mux.Vars(r)["project_id"])
+	if project == nil {
+		return
+	}
+
+	callerID, _ := api.UserIDFromContext(ctx)
+	if callerID != project.OwnerID {
+		config.ErrorStatus("only the project owner can archive or unarchive the project", http.StatusForbidden, w, nil)
+		return
+	}
+
+	now := time.Now().UTC()
+	change := models.ProjectChange{Type: models.ProjectArchived, UserID: project.OwnerID, By: callerID, At: now}
+
+	// only change it if nobody else did since we read it
+	filter := bson.M{"_id": project.ID, "archived": bson.M{"$ne": true}}
+	update := bson.M{"$set": bson.M{"archived": true, "archivedAt": now}}
+	if !archived {
+		change.Type = models.ProjectUnarchived
+		filter["archived"] = true
+		update = bson.M{"$set": bson.M{"archived": false}, "$unset": bson.M{"archivedAt": ""}}
+	}
+	update["$push"] = bson.M{"history": change}
+
+	dbResp, err := membership.Projects.UpdateOne(ctx, filter, update)
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+	if dbResp.MatchedCount == 0 && archived {
+		config.ErrorStatus("the project is already archived", http.StatusConflict, w, nil)
+		return
+	}
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("the project is not archived", http.StatusConflict, w, nil)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": change},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
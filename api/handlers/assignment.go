@@ -0,0 +1,456 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// AssignReportHandler adds assignees to a report the caller can read. Admins can
+// assign any project member while members can only assign themselves
+func (report Report) AssignReportHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.AssignDetails
+
+	reportID := mux.Vars(r)["report_id"]
+
+	rID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	// assignees read reports in full, so nobody can assign themselves to a
+	// private report they can't already read
+	current, _ := report.Members.readableReport(w, r, rID)
+	if current == nil {
+		return
+	}
+
+	pID, err := primitive.ObjectIDFromHex(current.ProjectID)
+	if err != nil {
+		config.ErrorStatus("report has an invalid project ID", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	project, err := report.Members.Projects.FindByID(ctx, pID)
+	if err != nil {
+		config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	if archived(w, project) {
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	if !isProjectAdmin(project, userID) && slices.ContainsFunc(details.UserIDs, func(id string) bool { return id != userID }) {
+		config.ErrorStatus("only project admins can assign other members", http.StatusForbidden, w, nil)
+		return
+	}
+
+	members, err := report.Members.allMembers(ctx, current.ProjectID, details.UserIDs)
+	if err != nil {
+		config.ErrorStatus("failed to get project members", http.StatusInternalServerError, w, err)
+		return
+	}
+	if !members {
+		config.ErrorStatus("assignees must be members of the project", http.StatusBadRequest, w, nil)
+		return
+	}
+
+	update := bson.M{"$addToSet": bson.M{"assigneeIds": bson.M{"$each": details.UserIDs}}}
+	dbResp, err := report.DB.UpdateByID(ctx, rID, update)
+	if err != nil {
+		config.ErrorStatus("the report could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("report not found", http.StatusNotFound, w, nil)
+		return
+	}
+
+	if err := report.Watchers.watch(ctx, details.UserIDs, models.WatchReport, reportID, current.ProjectID, models.WatchAssignee); err != nil {
+		config.ErrorStatus("failed to watch report", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// UnassignReportHandler removes an assignee from a report, admins can unassign
+// anyone and assignees can unassign themselves
+func (report Report) UnassignReportHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	reportID := mux.Vars(r)["report_id"]
+	assigneeID := mux.Vars(r)["user_id"]
+
+	rID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	current, err := report.DB.FindByID(ctx, rID)
+	if err != nil {
+		config.ErrorStatus("failed to get report by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	if report.Members.readOnly(w, r, current.ProjectID) {
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	if userID != assigneeID && projectAdmin(ctx, w, r, report.Members.Projects, current.ProjectID) == nil {
+		return
+	}
+
+	if !slices.Contains(current.AssigneeIDs, assigneeID) {
+		config.ErrorStatus("user is not assigned to the report", http.StatusNotFound, w, nil)
+		return
+	}
+
+	dbResp, err := report.DB.UpdateByID(ctx, rID, bson.M{"$pull": bson.M{"assigneeIds": assigneeID}})
+	if err != nil {
+		config.ErrorStatus("the report could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// AssignedReportsHandler returns a page of the reports a user is assigned to
+// across every project, newest first unless reportSort says otherwise,
+// narrowed by the filters reportFilter reads from the query
+func (report Report) AssignedReportsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := mux.Vars(r)["user_id"]
+
+	filter, err := reportFilter(r.URL.Query())
+	if err != nil {
+		config.ErrorStatus("invalid report filter", http.StatusBadRequest, w, err)
+		return
+	}
+	filter["assigneeIds"] = userID
+
+	sort, err := reportSort(r.URL.Query(), reportSorts["newest"])
+	if err != nil {
+		config.ErrorStatus("invalid report sort", http.StatusBadRequest, w, err)
+		return
+	}
+
+	// only list the reports the caller can read
+	re, err := report.Members.reader(ctx)
+	if err != nil {
+		config.ErrorStatus("failed to check report visibility", http.StatusInternalServerError, w, err)
+		return
+	}
+	filter["$and"] = bson.A{re.filter()}
+
+	dbResp, err := report.DB.FindPage(ctx, filter, pageFromRequest(r), sort)
+	if err != nil {
+		config.ErrorStatus("failed to get assigned reports", http.StatusInternalServerError, w, err)
+		return
+	}
+	re.present(dbResp.Items)
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// UpdateAutoAssignHandler sets how new reports of a project are assigned, only project admins can change it
+func (project Project) UpdateAutoAssignHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var autoAssign models.AutoAssign
+
+	projectID := mux.Vars(r)["project_id"]
+
+	current := projectAdmin(ctx, w, r, project.DB, projectID)
+	if current == nil || archived(w, current) {
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&autoAssign); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&autoAssign); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	assignees := []string{}
+	for _, rule := range autoAssign.Rules {
+		if !slices.Contains(labelNames(current), rule.Label) {
+			config.ErrorStatus(fmt.Sprintf("%q is not one of the project's labels", rule.Label), http.StatusBadRequest, w, nil)
+			return
+		}
+		assignees = append(assignees, rule.AssigneeIDs...)
+	}
+
+	members, err := project.Members.allMembers(ctx, projectID, assignees)
+	if err != nil {
+		config.ErrorStatus("failed to get project members", http.StatusInternalServerError, w, err)
+		return
+	}
+	if !members {
+		config.ErrorStatus("assignees must be members of the project", http.StatusBadRequest, w, nil)
+		return
+	}
+
+	// keep the round robin going from where it was
+	if current.AutoAssign != nil {
+		autoAssign.LastAssignee = current.AutoAssign.LastAssignee
+	}
+
+	dbResp, err := project.DB.UpdateByID(ctx, current.ID, bson.M{"$set": bson.M{"autoAssign": autoAssign}})
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// DeleteAutoAssignHandler turns off auto-assignment for a project, only project admins can change it
+func (project Project) DeleteAutoAssignHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := mux.Vars(r)["project_id"]
+
+	current := projectAdmin(ctx, w, r, project.DB, projectID)
+	if current == nil || archived(w, current) {
+		return
+	}
+
+	dbResp, err := project.DB.UpdateByID(ctx, current.ID, bson.M{"$unset": bson.M{"autoAssign": ""}})
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// autoAssign returns who a new report with the given labels should be assigned
+// to under the project's auto-assignment rules
+func (report Report) autoAssign(ctx context.Context, project *models.Project, labels []string) ([]string, error) {
+	if project.AutoAssign == nil {
+		return []string{}, nil
+	}
+
+	switch project.AutoAssign.Strategy {
+	case models.AutoAssignRoundRobin:
+		// the turn is only taken by takeTurn once the report exists
+		return []string{roundRobinNext(project)}, nil
+	case models.AutoAssignByLabel:
+		for _, rule := range project.AutoAssign.Rules {
+			if !slices.Contains(labels, rule.Label) {
+				continue
+			}
+
+			// skip anyone who left the project since the rule was written
+			memberships, err := report.Members.DB.Find(ctx, bson.M{"projectId": project.ID.Hex(), "userId": bson.M{"$in": rule.AssigneeIDs}})
+			if err != nil {
+				return nil, err
+			}
+			assignees := []string{}
+			for _, m := range memberships {
+				assignees = append(assignees, m.UserID)
+			}
+			return assignees, nil
+		}
+	}
+	return []string{}, nil
+}
+
+// roundRobinNext returns who the round robin strategy assigns next, the owner
+// followed by the admins in order
+func roundRobinNext(project *models.Project) string {
+	candidates := []string{project.OwnerID}
+	for _, adminID := range project.AdminsIDs {
+		if !slices.Contains(candidates, adminID) {
+			candidates = append(candidates, adminID)
+		}
+	}
+
+	// an unknown last assignee gives -1 so we start again at the owner
+	return candidates[(slices.Index(candidates, project.AutoAssign.LastAssignee)+1)%len(candidates)]
+}
+
+// takeTurn moves a project's round robin on to the assignee of a report that
+// was just created. When another report took that turn since the project was
+// read, the report is handed the next turn instead and its assignees updated
+func (report Report) takeTurn(ctx context.Context, project *models.Project, created *models.Report) error {
+	for range 5 {
+		if project.AutoAssign == nil || project.AutoAssign.Strategy != models.AutoAssignRoundRobin {
+			return nil
+		}
+
+		// only move on from the turn we read, the field is unset before the first turn
+		previous := any(project.AutoAssign.LastAssignee)
+		if project.AutoAssign.LastAssignee == "" {
+			previous = bson.M{"$exists": false}
+		}
+		filter := bson.M{"_id": project.ID, "autoAssign.strategy": models.AutoAssignRoundRobin, "autoAssign.lastAssignee": previous}
+
+		next := roundRobinNext(project)
+		dbResp, err := report.Members.Projects.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"autoAssign.lastAssignee": next}})
+		if err != nil {
+			return err
+		}
+
+		if dbResp.MatchedCount > 0 {
+			if slices.Equal(created.AssigneeIDs, []string{next}) {
+				return nil
+			}
+			created.AssigneeIDs = []string{next}
+			_, err := report.DB.UpdateByID(ctx, created.ID, bson.M{"$set": bson.M{"assigneeIds": created.AssigneeIDs}})
+			return err
+		}
+
+		project, err = report.Members.Projects.FindByID(ctx, project.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return errors.New("the round robin kept moving while the report was assigned")
+}
+
+// allMembers reports whether every user is a member of the project
+func (membership Membership) allMembers(ctx context.Context, projectID string, userIDs []string) (bool, error) {
+	unique := []string{}
+	for _, id := range userIDs {
+		if !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) == 0 {
+		return true, nil
+	}
+
+	count, err := membership.DB.Count(ctx, bson.M{"projectId": projectID, "userId": bson.M{"$in": unique}})
+	if err != nil {
+		return false, err
+	}
+	return count == int64(len(unique)), nil
+}
+
+// memberIDs returns the users that are members of the project, in the order given
+func (membership Membership) memberIDs(ctx context.Context, projectID string, userIDs []string) ([]string, error) {
+	members := []string{}
+	if len(userIDs) == 0 {
+		return members, nil
+	}
+
+	memberships, err := membership.DB.Find(ctx, bson.M{"projectId": projectID, "userId": bson.M{"$in": userIDs}})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, id := range userIDs {
+		if slices.ContainsFunc(memberships, func(m models.Membership) bool { return m.UserID == id }) {
+			members = append(members, id)
+		}
+	}
+	return members, nil
+}
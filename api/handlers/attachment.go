@@ -0,0 +1,450 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// attachmentTypes are the sniffed content types that can be uploaded. Anything
+// a browser could run, such as HTML or SVG, is left out on purpose
+var attachmentTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"video/mp4",
+	"video/webm",
+	"application/pdf",
+	"application/zip",
+	"application/x-gzip",
+	"text/plain; charset=utf-8",
+}
+
+// multipartMemory is how much of an upload is kept in memory before the rest spills to disk
+const multipartMemory = 1 << 20
+
+// Attachment handles files uploaded to reports and comments. The metadata is
+// kept in DB and the contents in Blobs under the attachment id
+type Attachment struct {
+	DB       databases.AttachmentDatabase
+	Blobs    databases.BlobStore
+	Comments databases.CommentDatabase
+	Members  Membership
+	MaxSize  int64 // Largest file in bytes that can be uploaded
+	Quota    int64 // Bytes of attachments each project can store
+}
+
+// UploadReportAttachmentHandler stores the file in the multipart "file" field as an attachment of a report
+func (attachment Attachment) UploadReportAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	rID, err := primitive.ObjectIDFromHex(mux.Vars(r)["report_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	attachment.upload(w, r, rID, "")
+}
+
+// UploadCommentAttachmentHandler stores the file in the multipart "file" field as an attachment of a comment
+func (attachment Attachment) UploadCommentAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	commentID := mux.Vars(r)["comment_id"]
+
+	cID, err := primitive.ObjectIDFromHex(commentID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	comment, err := attachment.Comments.FindByID(r.Context(), cID)
+	if err != nil {
+		config.ErrorStatus("failed to get comment by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	rID, err := primitive.ObjectIDFromHex(comment.ReportID)
+	if err != nil {
+		config.ErrorStatus("comment has an invalid report ID", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	attachment.upload(w, r, rID, commentID)
+}
+
+// ReportAttachmentsHandler lists the attachments of a report and its comments
+func (attachment Attachment) ReportAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	reportID := mux.Vars(r)["report_id"]
+
+	rID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	if attachment.report(w, r, rID) == nil {
+		return
+	}
+
+	dbResp, err := attachment.DB.Find(ctx, bson.M{"reportId": reportID}, databases.FindOptions{Sort: bson.D{{Key: "createdAt", Value: 1}}})
+	if err != nil {
+		config.ErrorStatus("failed to get attachments", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// DownloadAttachmentHandler streams the contents of an attachment to the report's
+// author, assignees and project members, members only once a private report is
+// disclosed. Images are shown inline, everything else is downloaded
+func (attachment Attachment) DownloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	aID, err := primitive.ObjectIDFromHex(mux.Vars(r)["attachment_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	current, err := attachment.DB.FindByID(ctx, aID)
+	if err != nil {
+		config.ErrorStatus("failed to get attachment by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	rID, err := primitive.ObjectIDFromHex(current.ReportID)
+	if err != nil {
+		config.ErrorStatus("attachment has an invalid report ID", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if attachment.report(w, r, rID) == nil {
+		return
+	}
+
+	contents, err := attachment.Blobs.Get(ctx, aID.Hex())
+	if err != nil {
+		config.ErrorStatus("failed to get attachment contents", http.StatusNotFound, w, err)
+		return
+	}
+	defer contents.Close()
+
+	disposition := "attachment"
+	if strings.HasPrefix(current.ContentType, "image/") {
+		disposition = "inline"
+	}
+
+	w.Header().Set("Content-Type", current.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(current.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": current.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	// the status is already sent so a failed copy can only be logged
+	if _, err := io.Copy(w, contents); err != nil {
+		zap.S().With(err).Error("failed to stream attachment")
+	}
+}
+
+// DeleteAttachmentHandler removes an attachment, uploaders can remove their own
+// attachments and project admins can remove any
+func (attachment Attachment) DeleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	aID, err := primitive.ObjectIDFromHex(mux.Vars(r)["attachment_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	current, err := attachment.DB.FindByID(ctx, aID)
+	if err != nil {
+		config.ErrorStatus("failed to get attachment by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	if current.UploaderID != userID && projectAdmin(ctx, w, r, attachment.Members.Projects, current.ProjectID) == nil {
+		return
+	}
+
+	if err := attachment.remove(ctx, bson.M{"_id": aID}); err != nil {
+		config.ErrorStatus("failed to remove attachment", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": current},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// report loads a report the authenticated user can read and checks they are
+// its author, one of its assignees or a member of its project. Private reports
+// stay limited to the author, assignees and project admins until disclosed.
+// When the user can't, or the report can't be loaded, the error response is
+// written and nil is returned
+func (attachment Attachment) report(w http.ResponseWriter, r *http.Request, rID primitive.ObjectID) *models.Report {
+	ctx := r.Context()
+
+	current, re := attachment.Members.readableReport(w, r, rID)
+	if current == nil {
+		return nil
+	}
+	if re.privileged(current) {
+		return current
+	}
+
+	pID, err := primitive.ObjectIDFromHex(current.ProjectID)
+	if err != nil {
+		config.ErrorStatus("report has an invalid project ID", http.StatusInternalServerError, w, err)
+		return nil
+	}
+
+	project, err := attachment.Members.Projects.FindByID(ctx, pID)
+	if err != nil {
+		config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, err)
+		return nil
+	}
+
+	roles, err := attachment.Members.roles(ctx, project, re.userID)
+	if err != nil {
+		config.ErrorStatus("failed to get project roles", http.StatusInternalServerError, w, err)
+		return nil
+	}
+	if len(roles) == 0 {
+		config.ErrorStatus("only the report author, assignees and project members can do this", http.StatusForbidden, w, nil)
+		return nil
+	}
+	return current
+}
+
+// upload checks the uploaded file's size and type, reserves room for it in the
+// project quota and stores it. Anything reserved or stored is given back when
+// a later step fails
+func (attachment Attachment) upload(w http.ResponseWriter, r *http.Request, rID primitive.ObjectID, commentID string) {
+	ctx := r.Context()
+
+	current := attachment.report(w, r, rID)
+	if current == nil {
+		return
+	}
+	if attachment.Members.readOnly(w, r, current.ProjectID) {
+		return
+	}
+
+	// leave some room for the multipart framing around the file
+	r.Body = http.MaxBytesReader(w, r.Body, attachment.MaxSize+multipartMemory)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			config.ErrorStatus("attachment is too large", http.StatusRequestEntityTooLarge, w, err)
+			return
+		}
+		config.ErrorStatus("failed to read multipart form", http.StatusBadRequest, w, err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		config.ErrorStatus("missing file field", http.StatusBadRequest, w, err)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > attachment.MaxSize {
+		config.ErrorStatus("attachment is too large", http.StatusRequestEntityTooLarge, w, nil)
+		return
+	}
+	if header.Size > attachment.Quota {
+		config.ErrorStatus("attachment is larger than the project's attachment quota", http.StatusRequestEntityTooLarge, w, nil)
+		return
+	}
+
+	// trust the contents rather than the type the client claims
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		config.ErrorStatus("failed to read attachment", http.StatusBadRequest, w, err)
+		return
+	}
+	contentType := http.DetectContentType(head[:n])
+	if !slices.Contains(attachmentTypes, contentType) {
+		config.ErrorStatus("attachments of type "+contentType+" are not allowed", http.StatusUnsupportedMediaType, w, nil)
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		config.ErrorStatus("failed to read attachment", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	pID, err := primitive.ObjectIDFromHex(current.ProjectID)
+	if err != nil {
+		config.ErrorStatus("report has an invalid project ID", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// the filter only matches while the file still fits, so concurrent uploads can't overshoot
+	reserve := bson.M{
+		"_id": pID,
+		"$or": bson.A{
+			bson.M{"storageUsed": bson.M{"$lte": attachment.Quota - header.Size}},
+			bson.M{"storageUsed": bson.M{"$exists": false}},
+		},
+	}
+	dbResp, err := attachment.Members.Projects.UpdateOne(ctx, reserve, bson.M{"$inc": bson.M{"storageUsed": header.Size}})
+	if err != nil {
+		config.ErrorStatus("failed to reserve attachment quota", http.StatusInternalServerError, w, err)
+		return
+	}
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("the project's attachment quota is used up", http.StatusRequestEntityTooLarge, w, nil)
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	newAttachment := models.Attachment{
+		ID:          primitive.NewObjectID(),
+		ProjectID:   current.ProjectID,
+		ReportID:    current.ID.Hex(),
+		CommentID:   commentID,
+		UploaderID:  userID,
+		Filename:    attachmentName(header.Filename),
+		ContentType: contentType,
+		Size:        header.Size,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	if err := attachment.Blobs.Put(ctx, newAttachment.ID.Hex(), file); err != nil {
+		attachment.release(ctx, pID, header.Size)
+		config.ErrorStatus("failed to store attachment", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if _, err := attachment.DB.InsertOne(ctx, &newAttachment); err != nil {
+		if err := attachment.Blobs.Delete(context.WithoutCancel(ctx), newAttachment.ID.Hex()); err != nil {
+			zap.S().With(err).Error("failed to remove orphaned attachment contents")
+		}
+		attachment.release(ctx, pID, header.Size)
+		config.ErrorStatus("failed to insert attachment", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusCreated,
+			Message: "success",
+			Data:    map[string]any{"result": newAttachment},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// release gives bytes back to a project's attachment quota. It runs after the
+// request may have failed so it ignores cancellation and only logs errors
+func (attachment Attachment) release(ctx context.Context, pID primitive.ObjectID, size int64) {
+	if size == 0 {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	if _, err := attachment.Members.Projects.UpdateByID(ctx, pID, bson.M{"$inc": bson.M{"storageUsed": -size}}); err != nil {
+		zap.S().With(err).Error("failed to release attachment quota")
+	}
+}
+
+// remove deletes the attachments matching filter along with their contents
+// and gives their size back to the project quota
+func (attachment Attachment) remove(ctx context.Context, filter bson.M) error {
+	attachments, err := attachment.DB.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range attachments {
+		if err := attachment.Blobs.Delete(ctx, a.ID.Hex()); err != nil {
+			return err
+		}
+		if _, err := attachment.DB.DeleteByID(ctx, a.ID); err != nil {
+			return err
+		}
+		if pID, err := primitive.ObjectIDFromHex(a.ProjectID); err == nil {
+			attachment.release(ctx, pID, a.Size)
+		}
+	}
+	return nil
+}
+
+// attachmentName keeps the last element of an uploaded file's name without
+// any control characters, falling back to a generic name when nothing is left
+func attachmentName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+
+	if name == "." || name == "/" || name == "" {
+		return "attachment"
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+	return name
+}
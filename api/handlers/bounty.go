@@ -0,0 +1,533 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// Bounty awards and pays rewards for reports. Every award and payment is
+// written to the ledger, which reward summaries are worked out from
+type Bounty struct {
+	DB       databases.BountyDatabase
+	Ledger   databases.LedgerDatabase
+	Reports  databases.ReportDatabase
+	Projects databases.ProjectDatabase
+
+	Reputation Reputation
+}
+
+// summarize totals ledger entries per currency, sorted by currency
+func summarize(entries []models.LedgerEntry) []models.RewardSummary {
+	summaries := []models.RewardSummary{}
+	for _, entry := range entries {
+		i := slices.IndexFunc(summaries, func(s models.RewardSummary) bool { return s.Currency == entry.Currency })
+		if i < 0 {
+			summaries = append(summaries, models.RewardSummary{Currency: entry.Currency})
+			i = len(summaries) - 1
+		}
+
+		switch entry.Type {
+		case models.LedgerAwarded:
+			summaries[i].Bounties++
+			summaries[i].Awarded += entry.Amount
+		case models.LedgerPaid:
+			summaries[i].Paid += entry.Amount
+		}
+		summaries[i].Pending = summaries[i].Awarded - summaries[i].Paid
+	}
+
+	slices.SortFunc(summaries, func(a, b models.RewardSummary) int { return strings.Compare(a.Currency, b.Currency) })
+	return summaries
+}
+
+// UpdateRewardsHandler replaces the reward table of a project, only project admins can change it.
+// Bounties already awarded keep their amounts
+func (project Project) UpdateRewardsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var table models.RewardTable
+
+	projectID := mux.Vars(r)["project_id"]
+
+	current := projectAdmin(ctx, w, r, project.DB, projectID)
+	if current == nil || archived(w, current) {
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&table); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&table); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	dbResp, err := project.DB.UpdateByID(ctx, current.ID, bson.M{"$set": bson.M{"rewards": table}})
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// DeleteRewardsHandler removes the reward table of a project, only project admins can remove it
+func (project Project) DeleteRewardsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := mux.Vars(r)["project_id"]
+
+	current := projectAdmin(ctx, w, r, project.DB, projectID)
+	if current == nil || archived(w, current) {
+		return
+	}
+
+	dbResp, err := project.DB.UpdateByID(ctx, current.ID, bson.M{"$unset": bson.M{"rewards": ""}})
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// AwardBountyHandler awards the author of a resolved report a bounty, only
+// admins of the report's project can award one. When the project has a reward
+// table the amount has to be within the tier for the report's severity
+func (bounty Bounty) AwardBountyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.BountyDetails
+
+	rID, err := primitive.ObjectIDFromHex(mux.Vars(r)["report_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	report, err := bounty.Reports.FindByID(ctx, rID)
+	if err != nil {
+		config.ErrorStatus("failed to get report by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	project := projectAdmin(ctx, w, r, bounty.Projects, report.ProjectID)
+	if project == nil {
+		return
+	}
+
+	if report.DuplicateOf != "" {
+		config.ErrorStatus("the report was merged into "+report.DuplicateOf+", award that one instead", http.StatusConflict, w, nil)
+		return
+	}
+	if !report.Resolved {
+		config.ErrorStatus("bounties can only be awarded for resolved reports", http.StatusConflict, w, nil)
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	if report.AuthorID == userID {
+		config.ErrorStatus("you cannot award a bounty for your own report", http.StatusBadRequest, w, nil)
+		return
+	}
+
+	severity := severityName(report.Severity)
+	currency := details.Currency
+	if table := project.Rewards; table != nil {
+		if currency == "" {
+			currency = table.Currency
+		}
+		if currency != table.Currency {
+			config.ErrorStatus("the project pays bounties in "+table.Currency, http.StatusBadRequest, w, nil)
+			return
+		}
+
+		i := slices.IndexFunc(table.Tiers, func(tier models.RewardTier) bool { return tier.Severity == severity })
+		if i < 0 {
+			config.ErrorStatus(fmt.Sprintf("the reward table pays nothing for %q reports", severity), http.StatusBadRequest, w, nil)
+			return
+		}
+		if tier := table.Tiers[i]; details.Amount < tier.Min || details.Amount > tier.Max {
+			config.ErrorStatus(fmt.Sprintf("bounties for %q reports are between %d and %d", severity, tier.Min, tier.Max), http.StatusBadRequest, w, nil)
+			return
+		}
+	}
+	if currency == "" {
+		config.ErrorStatus("a currency is required when the project has no reward table", http.StatusBadRequest, w, nil)
+		return
+	}
+
+	newBounty := models.Bounty{
+		ID:        primitive.NewObjectID(),
+		ReportID:  report.ID.Hex(),
+		ProjectID: report.ProjectID,
+		UserID:    report.AuthorID,
+		Severity:  severity,
+		Amount:    details.Amount,
+		Currency:  currency,
+		Status:    models.BountyPending,
+		Note:      details.Note,
+		AwardedBy: userID,
+		AwardedAt: time.Now().UTC(),
+	}
+
+	_, err = bounty.DB.InsertOne(ctx, &newBounty)
+	if errors.Is(err, databases.ErrDuplicateKey) {
+		config.ErrorStatus("a bounty was already awarded for this report", http.StatusConflict, w, err)
+		return
+	}
+	if err != nil {
+		config.ErrorStatus("failed to insert bounty", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if err := bounty.record(ctx, &newBounty, models.LedgerAwarded, userID, newBounty.AwardedAt); err != nil {
+		config.ErrorStatus("failed to record bounty in the ledger", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if err := bounty.Reputation.sync(ctx, newBounty.ReportID); err != nil {
+		config.ErrorStatus("failed to update reputation", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusCreated,
+			Message: "success",
+			Data:    map[string]any{"result": newBounty},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// PayBountyHandler marks a pending bounty as paid, only admins of its project can pay it
+func (bounty Bounty) PayBountyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bID, err := primitive.ObjectIDFromHex(mux.Vars(r)["bounty_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	current, err := bounty.DB.FindByID(ctx, bID)
+	if err != nil {
+		config.ErrorStatus("failed to get bounty by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	if projectAdmin(ctx, w, r, bounty.Projects, current.ProjectID) == nil {
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	now := time.Now().UTC()
+
+	// only pay if nobody paid the bounty since we read it
+	filter := bson.M{"_id": bID, "status": models.BountyPending}
+	update := bson.M{"$set": bson.M{"status": models.BountyPaid, "paidBy": userID, "paidAt": now}}
+	dbResp, err := bounty.DB.UpdateOne(ctx, filter, update)
+	if err != nil {
+		config.ErrorStatus("the bounty could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("the bounty was already paid", http.StatusConflict, w, nil)
+		return
+	}
+
+	current.Status, current.PaidBy, current.PaidAt = models.BountyPaid, userID, &now
+	if err := bounty.record(ctx, current, models.LedgerPaid, userID, now); err != nil {
+		config.ErrorStatus("failed to record payment in the ledger", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": current},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// record adds an entry for something an admin did to a bounty to the ledger
+func (bounty Bounty) record(ctx context.Context, awarded *models.Bounty, entryType, actorID string, at time.Time) error {
+	_, err := bounty.Ledger.InsertOne(ctx, &models.LedgerEntry{
+		ID:        primitive.NewObjectID(),
+		Type:      entryType,
+		BountyID:  awarded.ID.Hex(),
+		ReportID:  awarded.ReportID,
+		ProjectID: awarded.ProjectID,
+		UserID:    awarded.UserID,
+		Amount:    awarded.Amount,
+		Currency:  awarded.Currency,
+		ActorID:   actorID,
+		CreatedAt: at,
+	})
+	return err
+}
+
+// ReportBountyHandler returns the bounty awarded for a report, only the
+// researcher it was awarded to and admins of its project can see it
+func (bounty Bounty) ReportBountyHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	dbResp, err := bounty.DB.FindOne(ctx, bson.M{"reportId": mux.Vars(r)["report_id"]})
+	if err != nil {
+		config.ErrorStatus("no bounty was awarded for this report", http.StatusNotFound, w, err)
+		return
+	}
+
+	if userID, _ := api.UserIDFromContext(ctx); dbResp.UserID != userID {
+		if projectAdmin(ctx, w, r, bounty.Projects, dbResp.ProjectID) == nil {
+			return
+		}
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// ProjectBountiesHandler returns a page of the bounties awarded in a project,
+// newest first and optionally only those with the status query parameter.
+// Only project admins can list them
+func (bounty Bounty) ProjectBountiesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := mux.Vars(r)["project_id"]
+
+	if projectAdmin(ctx, w, r, bounty.Projects, projectID) == nil {
+		return
+	}
+
+	filter := bson.M{"projectId": projectID}
+	if status := r.URL.Query().Get("status"); status != "" {
+		if status != models.BountyPending && status != models.BountyPaid {
+			config.ErrorStatus("status must be pending or paid", http.StatusBadRequest, w, nil)
+			return
+		}
+		filter["status"] = status
+	}
+
+	newestFirst := databases.FindOptions{Sort: bson.D{{Key: "awardedAt", Value: -1}}}
+	dbResp, err := bounty.DB.FindPage(ctx, filter, pageFromRequest(r), newestFirst)
+	if err != nil {
+		config.ErrorStatus("failed to get bounties", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// ProjectLedgerHandler returns a page of a project's ledger entries, newest
+// first. Only project admins can read the ledger
+func (bounty Bounty) ProjectLedgerHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := mux.Vars(r)["project_id"]
+
+	if projectAdmin(ctx, w, r, bounty.Projects, projectID) == nil {
+		return
+	}
+
+	newestFirst := databases.FindOptions{Sort: bson.D{{Key: "createdAt", Value: -1}}}
+	dbResp, err := bounty.Ledger.FindPage(ctx, bson.M{"projectId": projectID}, pageFromRequest(r), newestFirst)
+	if err != nil {
+		config.ErrorStatus("failed to get ledger entries", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// ProjectRewardsHandler totals the bounties a project has awarded and paid
+// per currency, only project admins can see them
+func (bounty Bounty) ProjectRewardsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := mux.Vars(r)["project_id"]
+
+	if projectAdmin(ctx, w, r, bounty.Projects, projectID) == nil {
+		return
+	}
+
+	entries, err := bounty.Ledger.Find(ctx, bson.M{"projectId": projectID})
+	if err != nil {
+		config.ErrorStatus("failed to get ledger entries", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": summarize(entries)},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// UserRewardsHandler totals the bounties a user has been awarded and paid per
+// currency along with a page of the bounties, newest first. Users can only see their own
+func (bounty Bounty) UserRewardsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := mux.Vars(r)["user_id"]
+
+	if caller, _ := api.UserIDFromContext(ctx); caller != userID {
+		config.ErrorStatus("you can only see your own rewards", http.StatusForbidden, w, nil)
+		return
+	}
+
+	entries, err := bounty.Ledger.Find(ctx, bson.M{"userId": userID})
+	if err != nil {
+		config.ErrorStatus("failed to get ledger entries", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	newestFirst := databases.FindOptions{Sort: bson.D{{Key: "awardedAt", Value: -1}}}
+	bounties, err := bounty.DB.FindPage(ctx, bson.M{"userId": userID}, pageFromRequest(r), newestFirst)
+	if err != nil {
+		config.ErrorStatus("failed to get bounties", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": summarize(entries), "bounties": bounties},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
@@ -1,22 +1,23 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
-	"time"
 
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
+	"github.com/BugBridge/bugbridge-api/api"
 	"github.com/BugBridge/bugbridge-api/config"
 	"github.com/BugBridge/bugbridge-api/databases"
 	"github.com/BugBridge/bugbridge-api/models"
 )
 
 type Comment struct {
-	DB databases.CommentDatabase
+	DB          databases.CommentDatabase
+	Attachments Attachment
+	Watchers    Subscription
 }
 
 // TODO: add delete and update functionality
@@ -31,12 +32,26 @@ func (comment Comment) CommentByObjectIDHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	dbResp, err := comment.DB.FindOne(context.Background(), bson.M{"_id": cID})
+	dbResp, err := comment.DB.FindByID(r.Context(), cID)
 	if err != nil {
 		config.ErrorStatus("failed to get comment by ID", http.StatusNotFound, w, err)
 		return
 	}
 
+	// comments can be read by whoever can read their report
+	rID, err := primitive.ObjectIDFromHex(dbResp.ReportID)
+	if err != nil {
+		config.ErrorStatus("comment has an invalid report ID", http.StatusInternalServerError, w, err)
+		return
+	}
+	report, re := comment.Attachments.Members.readableReport(w, r, rID)
+	if report == nil {
+		return
+	}
+	if !re.privileged(report) {
+		dbResp.Content = redactor(report).Replace(dbResp.Content)
+	}
+
 	b, err := json.Marshal(
 		models.DataResponse{
 			Status:  http.StatusOK,
@@ -58,7 +73,18 @@ func (comment Comment) CommentByObjectIDHandler(w http.ResponseWriter, r *http.R
 func (comment Comment) CommentsByReportIDHandler(w http.ResponseWriter, r *http.Request) {
 	reportID := mux.Vars(r)["report_id"]
 
-	dbResp, err := comment.DB.Find(context.Background(), bson.M{"reportId": reportID})
+	rID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	report, re := comment.Attachments.Members.readableReport(w, r, rID)
+	if report == nil {
+		return
+	}
+
+	dbResp, err := comment.DB.Find(r.Context(), bson.M{"reportId": reportID})
 	if err != nil {
 		config.ErrorStatus("failed to get comment by ID", http.StatusNotFound, w, err)
 		return
@@ -68,6 +94,13 @@ func (comment Comment) CommentsByReportIDHandler(w http.ResponseWriter, r *http.
 		dbResp = []models.Comment{}
 	}
 
+	if !re.privileged(report) {
+		replacer := redactor(report)
+		for i := range dbResp {
+			dbResp[i].Content = replacer.Replace(dbResp[i].Content)
+		}
+	}
+
 	b, err := json.Marshal(
 		models.DataResponse{
 			Status:  http.StatusOK,
@@ -87,9 +120,8 @@ func (comment Comment) CommentsByReportIDHandler(w http.ResponseWriter, r *http.
 
 // Create a new comment
 func (comment Comment) NewCommentHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx := r.Context()
 	var details models.CommentDetails // Json data will represent the report details model
-	defer cancel()
 
 	// validate the request body
 	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
@@ -105,6 +137,20 @@ func (comment Comment) NewCommentHandler(w http.ResponseWriter, r *http.Request)
 
 	// TODO: add validation comment attributes
 
+	rID, err := primitive.ObjectIDFromHex(details.ReportID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	report, _ := comment.Attachments.Members.readableReport(w, r, rID)
+	if report == nil {
+		return
+	}
+	if comment.Attachments.Members.readOnly(w, r, report.ProjectID) {
+		return
+	}
+
 	newComment := models.Comment{
 		ID:       primitive.NewObjectID(),
 		AuthorID: details.AuthorID,
@@ -112,12 +158,24 @@ func (comment Comment) NewCommentHandler(w http.ResponseWriter, r *http.Request)
 		Content:  details.Content,
 	}
 
-	result, err := comment.DB.InsertOne(ctx, newComment)
+	result, err := comment.DB.InsertOne(ctx, &newComment)
 	if err != nil {
 		config.ErrorStatus("failed to insert comment", http.StatusBadRequest, w, err)
 		return
 	}
 
+	// the watch and the first response go to whoever is signed in, not the author the body names
+	callerID, _ := api.UserIDFromContext(ctx)
+	if err := comment.Watchers.watch(ctx, []string{callerID}, models.WatchReport, details.ReportID, report.ProjectID, models.WatchCommenter); err != nil {
+		config.ErrorStatus("failed to watch report", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if err := comment.firstResponse(ctx, report, callerID); err != nil {
+		config.ErrorStatus("failed to record first response", http.StatusInternalServerError, w, err)
+		return
+	}
+
 	b, err := json.Marshal(
 		models.DataResponse{
 			Status:  http.StatusCreated,
@@ -137,9 +195,8 @@ func (comment Comment) NewCommentHandler(w http.ResponseWriter, r *http.Request)
 
 // UpdateCommentHandler updates the content for an existing comment
 func (comment Comment) UpdateCommentHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx := r.Context()
 	var newDetails models.CommentUpdateDetails
-	defer cancel()
 
 	commentID := mux.Vars(r)["comment_id"]
 
@@ -162,9 +219,9 @@ func (comment Comment) UpdateCommentHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	dbResp, err := comment.DB.UpdateOne(
+	dbResp, err := comment.DB.UpdateByID(
 		ctx,
-		bson.M{"_id": cID},
+		cID,
 		bson.M{"$set": bson.M{"content": newDetails.Content}},
 	)
 
@@ -173,6 +230,11 @@ func (comment Comment) UpdateCommentHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("Comment not found", http.StatusNotFound, w, nil)
+		return
+	}
+
 	b, err := json.Marshal(
 		models.DataResponse{
 			Status:  http.StatusOK,
@@ -191,8 +253,7 @@ func (comment Comment) UpdateCommentHandler(w http.ResponseWriter, r *http.Reque
 }
 
 func (comment Comment) DeleteCommentByIdHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	commentID := mux.Vars(r)["comment_id"]
 
@@ -203,17 +264,22 @@ func (comment Comment) DeleteCommentByIdHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	dbResp, err := comment.DB.DeleteOne(ctx, bson.M{"_id": uID})
+	dbResp, err := comment.DB.DeleteByID(ctx, uID)
 	if err != nil {
 		config.ErrorStatus("failed to delete comment", http.StatusNotFound, w, err)
 		return
 	}
 
-	if dbResp.Dr.DeletedCount == 0 {
+	if dbResp.DeletedCount == 0 {
 		config.ErrorStatus("Comment not found", http.StatusNotFound, w, nil)
 		return
 	}
 
+	if err := comment.Attachments.remove(ctx, bson.M{"commentId": commentID}); err != nil {
+		config.ErrorStatus("failed to remove comment attachments", http.StatusInternalServerError, w, err)
+		return
+	}
+
 	b, err := json.Marshal(
 		models.DataResponse{
 			Status:  http.StatusOK,
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// maxFieldLength is the longest a string custom field value can be
+const maxFieldLength = 500
+
+// validateFields checks custom field values against a project's field definitions
+// and returns them in the form they are stored in, nil values count as missing
+func validateFields(fields []models.CustomField, values map[string]any) (map[string]any, error) {
+	stored := map[string]any{}
+	for key, value := range values {
+		if value == nil {
+			continue
+		}
+
+		i := slices.IndexFunc(fields, func(field models.CustomField) bool { return field.Key == key })
+		if i < 0 {
+			return nil, fmt.Errorf("field %q is not one of the project's custom fields", key)
+		}
+
+		value, err := fieldValue(fields[i], value)
+		if err != nil {
+			return nil, err
+		}
+		stored[key] = value
+	}
+
+	for _, field := range fields {
+		if _, ok := stored[field.Key]; field.Required && !ok {
+			return nil, fmt.Errorf("field %q is required", field.Key)
+		}
+	}
+	return stored, nil
+}
+
+// fieldValue converts a value decoded from a request or read back from the
+// database into the form a field stores, dates are kept as 2006-01-02 strings
+// so they sort and compare as dates
+func fieldValue(field models.CustomField, value any) (any, error) {
+	if field.Type == models.FieldNumber {
+		switch number := value.(type) {
+		case float64:
+			return number, nil
+		case int32:
+			return float64(number), nil
+		case int64:
+			return float64(number), nil
+		default:
+			return nil, fmt.Errorf("field %q must be a number", field.Key)
+		}
+	}
+
+	if field.Type == models.FieldMultiSelect {
+		items, ok := value.([]any)
+		if array, isArray := value.(primitive.A); isArray {
+			items, ok = array, true
+		}
+		if !ok {
+			return nil, fmt.Errorf("field %q must be a list of options", field.Key)
+		}
+
+		selected := []string{}
+		for _, item := range items {
+			option, ok := item.(string)
+			if !ok || !slices.Contains(field.Options, option) {
+				return nil, fmt.Errorf("field %q must only contain its options", field.Key)
+			}
+			if !slices.Contains(selected, option) {
+				selected = append(selected, option)
+			}
+		}
+		return selected, nil
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("field %q must be a string", field.Key)
+	}
+
+	switch field.Type {
+	case models.FieldEnum:
+		if !slices.Contains(field.Options, text) {
+			return nil, fmt.Errorf("field %q must be one of its options", field.Key)
+		}
+	case models.FieldURL:
+		u, err := url.ParseRequestURI(text)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("field %q must be an http or https URL", field.Key)
+		}
+	case models.FieldDate:
+		if _, err := time.Parse(time.DateOnly, text); err != nil {
+			return nil, fmt.Errorf("field %q must be a date like 2006-01-02", field.Key)
+		}
+	}
+
+	if utf8.RuneCountInString(text) > maxFieldLength {
+		return nil, fmt.Errorf("field %q must be at most %d characters", field.Key, maxFieldLength)
+	}
+	return text, nil
+}
+
+// UpdateCustomFieldsHandler replaces the custom fields of a project, only project admins can change them.
+// Values of fields that are dropped or change type are removed from every report of the project
+func (project Project) UpdateCustomFieldsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.CustomFieldsDetails
+
+	projectID := mux.Vars(r)["project_id"]
+
+	current := projectAdmin(ctx, w, r, project.DB, projectID)
+	if current == nil || archived(w, current) {
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	if details.Fields == nil {
+		details.Fields = []models.CustomField{}
+	}
+
+	dbResp, err := project.DB.UpdateByID(ctx, current.ID, bson.M{"$set": bson.M{"customFields": details.Fields}})
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if err := project.dropFieldValues(ctx, projectID, current.CustomFields, details.Fields); err != nil {
+		config.ErrorStatus("failed to remove field values from reports", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// dropFieldValues removes the values of fields in before that are missing from
+// after, or have a different type there, from every report of a project
+func (project Project) dropFieldValues(ctx context.Context, projectID string, before, after []models.CustomField) error {
+	unset := bson.M{}
+	for _, old := range before {
+		i := slices.IndexFunc(after, func(field models.CustomField) bool { return field.Key == old.Key })
+		if i < 0 || after[i].Type != old.Type {
+			unset["fields."+old.Key] = ""
+		}
+	}
+	if len(unset) == 0 {
+		return nil
+	}
+
+	_, err := project.Members.Reports.UpdateMany(ctx, bson.M{"projectId": projectID}, bson.M{"$unset": unset})
+	return err
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+func TestValidateFields(t *testing.T) {
+	fields := []models.CustomField{
+		{Key: "browser", Type: models.FieldEnum, Options: []string{"chrome", "firefox"}, Required: true},
+		{Key: "build", Type: models.FieldNumber},
+		{Key: "notes", Type: models.FieldString},
+		{Key: "link", Type: models.FieldURL},
+		{Key: "found", Type: models.FieldDate},
+		{Key: "os", Type: models.FieldMultiSelect, Options: []string{"linux", "macos", "windows"}},
+	}
+
+	cases := []struct {
+		name   string
+		values map[string]any
+		stored map[string]any
+		err    string // part of the error message, empty when the values are valid
+	}{
+		{
+			name:   "only the required field",
+			values: map[string]any{"browser": "chrome"},
+			stored: map[string]any{"browser": "chrome"},
+		},
+		{
+			// numbers read back from the database are integers, and repeated options are dropped
+			name: "every type",
+			values: map[string]any{
+				"browser": "firefox",
+				"build":   int32(42),
+				"notes":   "crashes on start",
+				"link":    "https://example.com/crash",
+				"found":   "2026-01-05",
+				"os":      primitive.A{"linux", "macos", "linux"},
+			},
+			stored: map[string]any{
+				"browser": "firefox",
+				"build":   float64(42),
+				"notes":   "crashes on start",
+				"link":    "https://example.com/crash",
+				"found":   "2026-01-05",
+				"os":      []string{"linux", "macos"},
+			},
+		},
+		{
+			name:   "missing required field",
+			values: map[string]any{"build": float64(1)},
+			err:    `field "browser" is required`,
+		},
+		{
+			name:   "required field cleared",
+			values: map[string]any{"browser": nil},
+			err:    `field "browser" is required`,
+		},
+		{
+			name:   "enum value not an option",
+			values: map[string]any{"browser": "safari"},
+			err:    `field "browser" must be one of its options`,
+		},
+		{
+			name:   "multi select value not an option",
+			values: map[string]any{"browser": "chrome", "os": []any{"linux", "beos"}},
+			err:    `field "os" must only contain its options`,
+		},
+		{
+			name:   "number given as a string",
+			values: map[string]any{"browser": "chrome", "build": "42"},
+			err:    `field "build" must be a number`,
+		},
+		{
+			name:   "string given as a number",
+			values: map[string]any{"browser": "chrome", "notes": float64(1)},
+			err:    `field "notes" must be a string`,
+		},
+		{
+			name:   "url without a scheme",
+			values: map[string]any{"browser": "chrome", "link": "example.com"},
+			err:    `field "link" must be an http or https URL`,
+		},
+		{
+			name:   "date in another format",
+			values: map[string]any{"browser": "chrome", "found": "05/01/2026"},
+			err:    `field "found" must be a date`,
+		},
+		{
+			name:   "string too long",
+			values: map[string]any{"browser": "chrome", "notes": strings.Repeat("a", maxFieldLength+1)},
+			err:    `field "notes" must be at most`,
+		},
+		{
+			name:   "unknown field",
+			values: map[string]any{"browser": "chrome", "version": "1.0"},
+			err:    `field "version" is not one of the project's custom fields`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			stored, err := validateFields(fields, tc.values)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				if !reflect.DeepEqual(stored, tc.stored) {
+					t.Errorf("stored %v, want %v", stored, tc.stored)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("error %v, want one containing %q", err, tc.err)
+			}
+		})
+	}
+}
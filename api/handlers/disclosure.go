@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// UpdateVisibilityHandler changes who can see a project and report to it, only
+// project admins can change it
+func (project Project) UpdateVisibilityHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.ProjectVisibilityDetails
+
+	current := projectAdmin(ctx, w, r, project.DB, mux.Vars(r)["project_id"])
+	if current == nil || archived(w, current) {
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	dbResp, err := project.DB.UpdateByID(ctx, current.ID, bson.M{"$set": bson.M{"visibility": details.Visibility}})
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// ScheduleDisclosureHandler schedules a report to be made public at the start
+// of a day in UTC, only project admins can schedule it. The redactions are
+// replaced in what readers other than the reporter, assignees and admins see.
+// Scheduling it again replaces the earlier schedule, reports scheduled for a
+// day that has already started are disclosed straight away
+func (report Report) ScheduleDisclosureHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.DisclosureDetails
+
+	rID, err := primitive.ObjectIDFromHex(mux.Vars(r)["report_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	current, err := report.DB.FindByID(ctx, rID)
+	if err != nil {
+		config.ErrorStatus("failed to get report by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	if projectAdmin(ctx, w, r, report.Members.Projects, current.ProjectID) == nil {
+		return
+	}
+
+	// the validator already checked the date parses
+	publishAt, _ := time.Parse(time.DateOnly, details.PublishOn)
+	if details.Redactions == nil {
+		details.Redactions = []string{}
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	now := time.Now().UTC()
+	disclosure := models.Disclosure{
+		PublishOn:   details.PublishOn,
+		PublishAt:   publishAt,
+		Redactions:  details.Redactions,
+		ScheduledBy: userID,
+		ScheduledAt: now,
+	}
+
+	// only schedule if nobody disclosed the report since we read it
+	filter := bson.M{"_id": rID, "visibility": bson.M{"$ne": models.ReportPublic}}
+	dbResp, err := report.DB.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"disclosure": disclosure}})
+	if err != nil {
+		config.ErrorStatus("the report could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("the report has already been disclosed", http.StatusConflict, w, nil)
+		return
+	}
+
+	if !publishAt.After(now) {
+		current.Disclosure = &disclosure
+		if _, err := report.Disclosures.disclose(ctx, current, now); err != nil {
+			config.ErrorStatus("failed to disclose report", http.StatusInternalServerError, w, err)
+			return
+		}
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// CancelDisclosureHandler cancels a report's scheduled disclosure, only project
+// admins can cancel it. Reports that have already been disclosed stay public
+func (report Report) CancelDisclosureHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rID, err := primitive.ObjectIDFromHex(mux.Vars(r)["report_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	current, err := report.DB.FindByID(ctx, rID)
+	if err != nil {
+		config.ErrorStatus("failed to get report by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	if projectAdmin(ctx, w, r, report.Members.Projects, current.ProjectID) == nil {
+		return
+	}
+
+	if current.Disclosure == nil {
+		config.ErrorStatus("the report has no disclosure scheduled", http.StatusNotFound, w, nil)
+		return
+	}
+
+	// only cancel if the report wasn't disclosed since we read it
+	filter := bson.M{"_id": rID, "visibility": bson.M{"$ne": models.ReportPublic}}
+	dbResp, err := report.DB.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"disclosure": ""}})
+	if err != nil {
+		config.ErrorStatus("the report could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("the report has already been disclosed", http.StatusConflict, w, nil)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// Disclosures periodically makes reports public once their disclosure date has
+// come and tells their watchers
+type Disclosures struct {
+	Reports databases.ReportDatabase
+	Events  Event
+}
+
+// check discloses every report scheduled for before now, returning how many
+// reports it disclosed
+func (disclosures Disclosures) check(ctx context.Context, now time.Time) (int, error) {
+	filter := bson.M{
+		"visibility":           bson.M{"$ne": models.ReportPublic},
+		"disclosure.publishAt": bson.M{"$lte": now},
+	}
+
+	reports, err := disclosures.Reports.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	disclosed := 0
+	for _, report := range reports {
+		ok, err := disclosures.disclose(ctx, &report, now)
+		if err != nil {
+			return disclosed, err
+		}
+		if ok {
+			disclosed++
+		}
+	}
+	return disclosed, nil
+}
+
+// disclose makes a report with a disclosure scheduled public and tells its
+// watchers, reporting false when it had already been disclosed
+func (disclosures Disclosures) disclose(ctx context.Context, report *models.Report, now time.Time) (bool, error) {
+	// another instance may have disclosed the report since we looked
+	filter := bson.M{"_id": report.ID, "visibility": bson.M{"$ne": models.ReportPublic}, "disclosure": bson.M{"$exists": true}}
+	update := bson.M{"$set": bson.M{"visibility": models.ReportPublic, "disclosure.disclosedAt": now}}
+
+	dbResp, err := disclosures.Reports.UpdateOne(ctx, filter, update)
+	if err != nil || dbResp.ModifiedCount == 0 {
+		return false, err
+	}
+
+	err = disclosures.Events.emit(ctx, models.Event{
+		Type:      models.EventReportDisclosed,
+		ProjectID: report.ProjectID,
+		ReportID:  report.ID.Hex(),
+		Data:      map[string]any{"publishOn": report.Disclosure.PublishOn},
+	})
+	return err == nil, err
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+	"github.com/BugBridge/bugbridge-api/util"
+)
+
+const (
+	duplicateScanLimit = 500  // most recent open reports compared against a new one
+	duplicateThreshold = 0.35 // lowest score a report needs to be suggested as a duplicate
+	duplicateMaxResult = 5    // most duplicates suggested at once
+	shingleSize        = 2    // longest run of words compared between reports
+	maxDuplicateChain  = 20   // most reports followed to find the one a duplicate was merged into
+)
+
+// duplicates returns the open reports of a project that look most like the
+// given title and description, best match first, out of the reports the
+// reader can read
+func (report Report) duplicates(ctx context.Context, re reader, projectID, title, des string) ([]models.DuplicateCandidate, error) {
+	filter := bson.M{"projectId": projectID, "resolved": false, "$and": bson.A{re.filter()}}
+	opts := databases.FindOptions{Sort: bson.D{{Key: "_id", Value: -1}}, Limit: duplicateScanLimit}
+
+	reports, err := report.DB.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	titleShingles := util.Shingles(title, shingleSize)
+	desShingles := util.Shingles(des, shingleSize)
+
+	candidates := []models.DuplicateCandidate{}
+	re.present(reports)
+	for _, other := range reports {
+		score := duplicateScore(titleShingles, desShingles, other)
+		if score < duplicateThreshold {
+			continue
+		}
+
+		candidates = append(candidates, models.DuplicateCandidate{
+			ReportID: other.ID.Hex(),
+			Title:    other.Title,
+			State:    other.State,
+			Score:    float64(int(score*100)) / 100,
+		})
+	}
+
+	slices.SortStableFunc(candidates, func(a, b models.DuplicateCandidate) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		}
+		return 0
+	})
+	if len(candidates) > duplicateMaxResult {
+		candidates = candidates[:duplicateMaxResult]
+	}
+	return candidates, nil
+}
+
+// duplicateScore scores how much a report looks like the title and description
+// shingles, titles and descriptions count for half the score each
+func duplicateScore(titleShingles, desShingles map[string]bool, other models.Report) float64 {
+	return util.Jaccard(titleShingles, util.Shingles(other.Title, shingleSize))/2 +
+		util.Jaccard(desShingles, util.Shingles(other.Des, shingleSize))/2
+}
+
+// CheckDuplicatesHandler returns the open reports of a project that look like
+// the report about to be submitted, so reporters can check before submitting
+func (report Report) CheckDuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.DuplicateCheckDetails
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	re, err := report.Members.reader(ctx)
+	if err != nil {
+		config.ErrorStatus("failed to check report visibility", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	candidates, err := report.duplicates(ctx, re, details.ProjectID, details.Title, details.Des)
+	if err != nil {
+		config.ErrorStatus("failed to look for duplicate reports", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": candidates},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// MergeReportHandler marks a report as a duplicate of another report of the same
+// project, only project admins can merge. The duplicate is resolved and its
+// comments, attachments, watchers and votes move to the report it was merged into
+func (report Report) MergeReportHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.ReportMergeDetails
+
+	rID, err := primitive.ObjectIDFromHex(mux.Vars(r)["report_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	current, err := report.DB.FindByID(ctx, rID)
+	if err != nil {
+		config.ErrorStatus("failed to get report by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	project := projectAdmin(ctx, w, r, report.Members.Projects, current.ProjectID)
+	if project == nil || archived(w, project) {
+		return
+	}
+
+	if current.DuplicateOf != "" {
+		config.ErrorStatus("the report has already been merged", http.StatusConflict, w, nil)
+		return
+	}
+
+	target, err := report.mergeTarget(ctx, details.Into)
+	if err != nil {
+		config.ErrorStatus("failed to get the report to merge into", http.StatusNotFound, w, err)
+		return
+	}
+	if target.ID == current.ID {
+		config.ErrorStatus("a report cannot be merged into itself", http.StatusBadRequest, w, nil)
+		return
+	}
+	if target.ProjectID != current.ProjectID {
+		config.ErrorStatus("reports can only be merged within a project", http.StatusBadRequest, w, nil)
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	reason := fmt.Sprintf("duplicate of %s", target.ID.Hex())
+	now := time.Now().UTC()
+
+	set, push := bson.M{"duplicateOf": target.ID.Hex(), "resolved": true, "resolution": reason}, bson.M{}
+	update := bson.M{"$set": set}
+	if current.SLA != nil && current.SLA.ResolvedAt == nil {
+		set["sla.resolvedAt"] = now
+	}
+
+	workflow := projectWorkflow(project)
+	if state, ok := workflowState(workflow, "duplicate"); ok && state.Terminal {
+		set["state"] = state.Name
+		push["history"] = models.StateChange{
+			From:   reportState(workflow, current),
+			To:     state.Name,
+			UserID: userID,
+			Reason: reason,
+			At:     now,
+		}
+	}
+
+	// record the merge as a link unless the reports are already linked
+	linked := slices.ContainsFunc(current.Links, func(link models.ReportLink) bool { return link.ReportID == target.ID.Hex() })
+	if !linked {
+		push["links"] = models.ReportLink{Type: models.LinkDuplicateOf, ReportID: target.ID.Hex(), CreatedBy: userID, CreatedAt: now}
+	}
+	if len(push) > 0 {
+		update["$push"] = push
+	}
+
+	// only merge if nobody merged the report since we read it
+	dbResp, err := report.DB.UpdateOne(ctx, bson.M{"_id": rID, "duplicateOf": bson.M{"$exists": false}}, update)
+	if err != nil {
+		config.ErrorStatus("the report could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("the report has already been merged", http.StatusConflict, w, nil)
+		return
+	}
+
+	if !linked {
+		inverse := models.ReportLink{Type: models.LinkDuplicatedBy, ReportID: current.ID.Hex(), CreatedBy: userID, CreatedAt: now}
+		if _, err := report.DB.UpdateByID(ctx, target.ID, bson.M{"$push": bson.M{"links": inverse}}); err != nil {
+			config.ErrorStatus("the report merged into could not be updated", http.StatusInternalServerError, w, err)
+			return
+		}
+	}
+
+	source, into := current.ID.Hex(), bson.M{"$set": bson.M{"reportId": target.ID.Hex()}}
+	if _, err := report.Attachments.Comments.UpdateMany(ctx, bson.M{"reportId": source}, into); err != nil {
+		config.ErrorStatus("failed to move comments", http.StatusInternalServerError, w, err)
+		return
+	}
+	if _, err := report.Attachments.DB.UpdateMany(ctx, bson.M{"reportId": source}, into); err != nil {
+		config.ErrorStatus("failed to move attachments", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if err := report.Watchers.move(ctx, source, target.ID.Hex(), target.ProjectID); err != nil {
+		config.ErrorStatus("failed to move watchers", http.StatusInternalServerError, w, err)
+		return
+	}
+	if err := report.Votes.move(ctx, source, target.ID.Hex()); err != nil {
+		config.ErrorStatus("failed to move votes", http.StatusInternalServerError, w, err)
+		return
+	}
+	if err := report.Reputation.sync(ctx, source); err != nil {
+		config.ErrorStatus("failed to update reputation", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// reports merged into this one earlier now point straight at the target
+	repoint := bson.M{"$set": bson.M{"duplicateOf": target.ID.Hex()}}
+	if _, err := report.DB.UpdateMany(ctx, bson.M{"duplicateOf": source}, repoint); err != nil {
+		config.ErrorStatus("failed to update earlier duplicates", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// mergeTarget loads the report with the given ID, following it to the report
+// it was merged into when it is a duplicate itself
+func (report Report) mergeTarget(ctx context.Context, reportID string) (*models.Report, error) {
+	for range maxDuplicateChain {
+		rID, err := primitive.ObjectIDFromHex(reportID)
+		if err != nil {
+			return nil, err
+		}
+
+		target, err := report.DB.FindByID(ctx, rID)
+		if err != nil {
+			return nil, err
+		}
+		if target.DuplicateOf == "" {
+			return target, nil
+		}
+		reportID = target.DuplicateOf
+	}
+	return nil, fmt.Errorf("report %s is part of a duplicate chain that is too long", reportID)
+}
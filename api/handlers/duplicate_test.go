@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/BugBridge/bugbridge-api/models"
+	"github.com/BugBridge/bugbridge-api/util"
+)
+
+func TestDuplicateThreshold(t *testing.T) {
+	cases := []struct {
+		name      string
+		title     string
+		des       string
+		other     models.Report
+		suggested bool
+	}{
+		{
+			name:      "identical",
+			title:     "Login button crashes app",
+			des:       "pressing login closes the app",
+			other:     models.Report{Title: "login button crashes app", Des: "Pressing login closes the app!"},
+			suggested: true,
+		},
+		{
+			// the titles overlap 7/10 and the descriptions not at all, exactly the threshold
+			name:      "on the threshold",
+			title:     "login button crashes app",
+			other:     models.Report{Title: "login button crashes app login again"},
+			suggested: true,
+		},
+		{
+			// one more word in the title drops the overlap to 7/12
+			name:  "just under the threshold",
+			title: "login button crashes app",
+			other: models.Report{Title: "login button crashes app login again today"},
+		},
+		{
+			name:  "unrelated",
+			title: "login button crashes app",
+			des:   "pressing login closes the app",
+			other: models.Report{Title: "slow search results", Des: "searching takes seconds"},
+		},
+		{
+			name:  "empty",
+			other: models.Report{Title: "login button crashes app"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			score := duplicateScore(util.Shingles(tc.title, shingleSize), util.Shingles(tc.des, shingleSize), tc.other)
+			if suggested := score >= duplicateThreshold; suggested != tc.suggested {
+				t.Errorf("score %v against threshold %v suggested %v, want %v", score, duplicateThreshold, suggested, tc.suggested)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// Event records what happens to reports and projects for the users watching them
+type Event struct {
+	DB       databases.EventDatabase
+	Watchers Subscription
+}
+
+// emit records an event for everyone watching its report or project who can
+// read the report, events nobody is watching are dropped
+func (event Event) emit(ctx context.Context, e models.Event) error {
+	recipients, err := event.Watchers.watchers(ctx, e.ProjectID, e.ReportID)
+	if err != nil {
+		return err
+	}
+	// watchers of a private report who can't read it don't hear about it
+	if e.ReportID != "" && len(recipients) > 0 {
+		recipients, err = event.readers(ctx, e.ReportID, recipients)
+		if err != nil {
+			return err
+		}
+	}
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	e.ID = primitive.NewObjectID()
+	e.Recipients = recipients
+	e.CreatedAt = time.Now().UTC()
+	if e.Data == nil {
+		e.Data = map[string]any{}
+	}
+
+	_, err = event.DB.InsertOne(ctx, &e)
+	return err
+}
+
+// readers returns the users who can read a report
+func (event Event) readers(ctx context.Context, reportID string, userIDs []string) ([]string, error) {
+	rID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := event.Watchers.Reports.FindByID(ctx, rID)
+	if err != nil {
+		return nil, err
+	}
+
+	readers := []string{}
+	for _, userID := range userIDs {
+		re, err := event.Watchers.Members.readerOf(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if re.canRead(report) {
+			readers = append(readers, userID)
+		}
+	}
+	return readers, nil
+}
+
+// UserEventsHandler returns a page of the events sent to a user, newest first.
+// Users can only list their own events
+func (event Event) UserEventsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := mux.Vars(r)["user_id"]
+
+	if caller, _ := api.UserIDFromContext(ctx); caller != userID {
+		config.ErrorStatus("you can only list your own events", http.StatusForbidden, w, nil)
+		return
+	}
+
+	newestFirst := databases.FindOptions{Sort: bson.D{{Key: "createdAt", Value: -1}}}
+	dbResp, err := event.DB.FindPage(ctx, bson.M{"recipients": userID}, pageFromRequest(r), newestFirst)
+	if err != nil {
+		config.ErrorStatus("failed to get events", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+const inviteTokenSize = 32 // random bytes in an invitation token
+
+type Invitation struct {
+	DB      databases.InvitationDatabase
+	Members Membership
+	TTL     time.Duration // How long invitations can be accepted for
+}
+
+// newInviteToken returns a random invitation token along with the hash stored for it
+func newInviteToken() (string, string, error) {
+	b := make([]byte, inviteTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, hashInviteToken(token), nil
+}
+
+// hashInviteToken returns the hash an invitation token is stored and looked up by
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// InviteMemberHandler invites someone to join a project with a role, only
+// project admins can invite. People are invited by email or by the username
+// of an existing user. The token answering the invitation is only returned
+// here, it has to be passed on to the invitee
+func (invitation Invitation) InviteMemberHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.InvitationDetails
+
+	projectID := mux.Vars(r)["project_id"]
+
+	if projectAdmin(ctx, w, r, invitation.Members.Projects, projectID) == nil {
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	now := time.Now().UTC()
+	newInvitation := models.Invitation{
+		ID:        primitive.NewObjectID(),
+		ProjectID: projectID,
+		Role:      details.Role,
+		Status:    models.InvitationPending,
+		InvitedBy: userID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(invitation.TTL),
+	}
+
+	// people already in the project can't be invited to it
+	invitee := bson.M{}
+	if details.Username != "" {
+		user, err := invitation.Members.Users.FindOne(ctx, bson.M{"username": details.Username})
+		if err != nil {
+			config.ErrorStatus("User not found", http.StatusNotFound, w, err)
+			return
+		}
+		newInvitation.UserID = user.ID.Hex()
+		invitee["userId"] = newInvitation.UserID
+	} else {
+		newInvitation.Email = strings.ToLower(details.Email)
+		invitee["email"] = newInvitation.Email
+	}
+
+	member, err := invitation.member(ctx, projectID, &newInvitation)
+	if err != nil {
+		config.ErrorStatus("failed to check project members", http.StatusInternalServerError, w, err)
+		return
+	}
+	if member {
+		config.ErrorStatus("user is already a member of the project", http.StatusConflict, w, nil)
+		return
+	}
+
+	invitee["projectId"] = projectID
+	invitee["status"] = models.InvitationPending
+	invitee["expiresAt"] = bson.M{"$gt": now}
+	pending, err := invitation.DB.Exists(ctx, invitee)
+	if err != nil {
+		config.ErrorStatus("failed to check pending invitations", http.StatusInternalServerError, w, err)
+		return
+	}
+	if pending {
+		config.ErrorStatus("an invitation to the project is already pending", http.StatusConflict, w, nil)
+		return
+	}
+
+	token, hash, err := newInviteToken()
+	if err != nil {
+		config.ErrorStatus("failed to create invitation token", http.StatusInternalServerError, w, err)
+		return
+	}
+	newInvitation.TokenHash = hash
+
+	if _, err := invitation.DB.InsertOne(ctx, &newInvitation); err != nil {
+		config.ErrorStatus("failed to insert invitation", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusCreated,
+			Message: "success",
+			Data:    map[string]any{"result": newInvitation, "token": token},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// member reports whether the user an invitation is for is already a member of the project
+func (invitation Invitation) member(ctx context.Context, projectID string, invite *models.Invitation) (bool, error) {
+	userID := invite.UserID
+	if userID == "" {
+		user, err := invitation.Members.Users.FindOne(ctx, bson.M{"email": invite.Email})
+		if errors.Is(err, databases.ErrNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		userID = user.ID.Hex()
+	}
+	return invitation.Members.DB.Exists(ctx, bson.M{"projectId": projectID, "userId": userID})
+}
+
+// ProjectInvitationsHandler returns a page of the invitations to a project that
+// can still be answered, newest first. Only project admins can see them
+func (invitation Invitation) ProjectInvitationsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := mux.Vars(r)["project_id"]
+
+	if projectAdmin(ctx, w, r, invitation.Members.Projects, projectID) == nil {
+		return
+	}
+
+	filter := bson.M{"projectId": projectID, "status": models.InvitationPending, "expiresAt": bson.M{"$gt": time.Now().UTC()}}
+	newestFirst := databases.FindOptions{Sort: bson.D{{Key: "createdAt", Value: -1}}}
+
+	dbResp, err := invitation.DB.FindPage(ctx, filter, pageFromRequest(r), newestFirst)
+	if err != nil {
+		config.ErrorStatus("failed to get project invitations", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// RevokeInvitationHandler withdraws a pending invitation so it can no longer
+// be answered, only project admins can revoke
+func (invitation Invitation) RevokeInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := mux.Vars(r)["project_id"]
+
+	iID, err := primitive.ObjectIDFromHex(mux.Vars(r)["invitation_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	if projectAdmin(ctx, w, r, invitation.Members.Projects, projectID) == nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	filter := bson.M{"_id": iID, "projectId": projectID, "status": models.InvitationPending}
+	update := bson.M{"$set": bson.M{"status": models.InvitationRevoked, "respondedAt": now}}
+
+	dbResp, err := invitation.DB.UpdateOne(ctx, filter, update)
+	if err != nil {
+		config.ErrorStatus("the invitation could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("Pending invitation not found", http.StatusNotFound, w, nil)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// AcceptInvitationHandler accepts the invitation with the token in the path,
+// making the authenticated user a member of the project with its role
+func (invitation Invitation) AcceptInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	current := invitation.invitee(w, r)
+	if current == nil {
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	member, err := invitation.Members.DB.Exists(ctx, bson.M{"projectId": current.ProjectID, "userId": userID})
+	if err != nil {
+		config.ErrorStatus("failed to check project members", http.StatusInternalServerError, w, err)
+		return
+	}
+	if member {
+		config.ErrorStatus("user is already a member of the project", http.StatusConflict, w, nil)
+		return
+	}
+
+	if !invitation.answer(w, r, current, models.InvitationAccepted) {
+		return
+	}
+
+	result, err := invitation.Members.add(ctx, current.ProjectID, userID, current.Role)
+	if errors.Is(err, databases.ErrDuplicateKey) {
+		config.ErrorStatus("user is already a member of the project", http.StatusConflict, w, err)
+		return
+	}
+	if err != nil {
+		config.ErrorStatus("failed to add member", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusCreated,
+			Message: "success",
+			Data:    map[string]any{"result": result},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// DeclineInvitationHandler declines the invitation with the token in the path
+func (invitation Invitation) DeclineInvitationHandler(w http.ResponseWriter, r *http.Request) {
+	current := invitation.invitee(w, r)
+	if current == nil {
+		return
+	}
+
+	if !invitation.answer(w, r, current, models.InvitationDeclined) {
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": current.ID},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// invitee loads the invitation with the token in the path and checks it can
+// still be answered by the authenticated user, who must be the user it was
+// sent to or have the address it was sent to. When it can't the error
+// response is written and nil is returned
+func (invitation Invitation) invitee(w http.ResponseWriter, r *http.Request) *models.Invitation {
+	ctx := r.Context()
+
+	current, err := invitation.DB.FindOne(ctx, bson.M{"tokenHash": hashInviteToken(mux.Vars(r)["token"])})
+	if err != nil {
+		config.ErrorStatus("Invitation not found", http.StatusNotFound, w, err)
+		return nil
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	uID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return nil
+	}
+
+	user, err := invitation.Members.Users.FindByID(ctx, uID)
+	if err != nil {
+		config.ErrorStatus("failed to get user by ID", http.StatusNotFound, w, err)
+		return nil
+	}
+
+	if current.UserID != userID && (current.Email == "" || !strings.EqualFold(current.Email, user.Email)) {
+		config.ErrorStatus("the invitation is for someone else", http.StatusForbidden, w, nil)
+		return nil
+	}
+
+	if current.Status != models.InvitationPending {
+		config.ErrorStatus(fmt.Sprintf("the invitation has already been %s", current.Status), http.StatusConflict, w, nil)
+		return nil
+	}
+	if !current.ExpiresAt.After(time.Now().UTC()) {
+		config.ErrorStatus("the invitation has expired", http.StatusGone, w, nil)
+		return nil
+	}
+	return current
+}
+
+// answer moves a pending invitation to accepted or declined, reporting false
+// with the error response written when it was answered or revoked since it
+// was loaded
+func (invitation Invitation) answer(w http.ResponseWriter, r *http.Request, current *models.Invitation, status string) bool {
+	now := time.Now().UTC()
+	filter := bson.M{"_id": current.ID, "status": models.InvitationPending}
+	update := bson.M{"$set": bson.M{"status": status, "respondedAt": now}}
+
+	dbResp, err := invitation.DB.UpdateOne(r.Context(), filter, update)
+	if err != nil {
+		config.ErrorStatus("the invitation could not be updated", http.StatusInternalServerError, w, err)
+		return false
+	}
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("the invitation has already been answered", http.StatusConflict, w, nil)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,388 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// reportTags describes a set of names a project defines and its reports can carry
+type reportTags struct {
+	field   string                         // report field holding the names
+	defined func(*models.Project) []string // names the project defines
+	carried func(*models.Report) []string  // names the report carries
+}
+
+var (
+	labelTags = reportTags{
+		field:   "labels",
+		defined: labelNames,
+		carried: func(report *models.Report) []string { return report.Labels },
+	}
+	componentTags = reportTags{
+		field:   "components",
+		defined: componentNames,
+		carried: func(report *models.Report) []string { return report.Components },
+	}
+)
+
+func labelNames(project *models.Project) []string {
+	names := []string{}
+	for _, label := range project.Labels {
+		names = append(names, label.Name)
+	}
+	return names
+}
+
+func componentNames(project *models.Project) []string {
+	names := []string{}
+	for _, component := range project.Components {
+		names = append(names, component.Name)
+	}
+	return names
+}
+
+// undefinedName returns the first name that is not in defined, or "" when they all are
+func undefinedName(names, defined []string) string {
+	for _, name := range names {
+		if !slices.Contains(defined, name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// UpdateLabelsHandler replaces the labels of a project, only project admins can change them.
+// Labels that are dropped are removed from every report of the project and the
+// auto-assignment rules for them go too, along with label auto-assignment when no rules are left
+func (project Project) UpdateLabelsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.LabelsDetails
+
+	projectID := mux.Vars(r)["project_id"]
+
+	current := projectAdmin(ctx, w, r, project.DB, projectID)
+	if current == nil || archived(w, current) {
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	if details.Labels == nil {
+		details.Labels = []models.Label{}
+	}
+
+	kept := labelNames(&models.Project{Labels: details.Labels})
+	set := bson.M{"labels": details.Labels}
+	update := bson.M{"$set": set}
+	if autoAssign := current.AutoAssign; autoAssign != nil && len(autoAssign.Rules) > 0 {
+		rules := slices.DeleteFunc(slices.Clone(autoAssign.Rules), func(rule models.LabelRule) bool {
+			return !slices.Contains(kept, rule.Label)
+		})
+		switch {
+		case len(rules) == 0 && autoAssign.Strategy == models.AutoAssignByLabel:
+			update["$unset"] = bson.M{"autoAssign": ""}
+		case len(rules) < len(autoAssign.Rules):
+			set["autoAssign.rules"] = rules
+		}
+	}
+
+	dbResp, err := project.DB.UpdateByID(ctx, current.ID, update)
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if err := project.dropTags(ctx, projectID, labelTags, labelNames(current), kept); err != nil {
+		config.ErrorStatus("failed to remove labels from reports", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// UpdateComponentsHandler replaces the components of a project, only project admins can change them.
+// Components that are dropped are removed from every report of the project
+func (project Project) UpdateComponentsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.ComponentsDetails
+
+	projectID := mux.Vars(r)["project_id"]
+
+	current := projectAdmin(ctx, w, r, project.DB, projectID)
+	if current == nil || archived(w, current) {
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	if details.Components == nil {
+		details.Components = []models.Component{}
+	}
+
+	dbResp, err := project.DB.UpdateByID(ctx, current.ID, bson.M{"$set": bson.M{"components": details.Components}})
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	kept := componentNames(&models.Project{Components: details.Components})
+	if err := project.dropTags(ctx, projectID, componentTags, componentNames(current), kept); err != nil {
+		config.ErrorStatus("failed to remove components from reports", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// dropTags removes the names in before but not in after from every report of a project
+func (project Project) dropTags(ctx context.Context, projectID string, tags reportTags, before, after []string) error {
+	removed := []string{}
+	for _, name := range before {
+		if !slices.Contains(after, name) {
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	filter := bson.M{"projectId": projectID, tags.field: bson.M{"$in": removed}}
+	_, err := project.Members.Reports.UpdateMany(ctx, filter, bson.M{"$pull": bson.M{tags.field: bson.M{"$in": removed}}})
+	return err
+}
+
+// AddLabelsHandler adds project labels to a report
+func (report Report) AddLabelsHandler(w http.ResponseWriter, r *http.Request) {
+	report.addTags(w, r, labelTags)
+}
+
+// RemoveLabelHandler removes a label from a report
+func (report Report) RemoveLabelHandler(w http.ResponseWriter, r *http.Request) {
+	report.removeTag(w, r, labelTags)
+}
+
+// AddComponentsHandler adds project components to a report
+func (report Report) AddComponentsHandler(w http.ResponseWriter, r *http.Request) {
+	report.addTags(w, r, componentTags)
+}
+
+// RemoveComponentHandler removes a component from a report
+func (report Report) RemoveComponentHandler(w http.ResponseWriter, r *http.Request) {
+	report.removeTag(w, r, componentTags)
+}
+
+// tagReport loads the report in the request and its project, checking the
+// authenticated user is a project member and the project isn't archived. When
+// either check fails, or either can't be loaded, the error response is written
+// and nil is returned
+func (report Report) tagReport(w http.ResponseWriter, r *http.Request) (*models.Report, *models.Project) {
+	ctx := r.Context()
+
+	rID, err := primitive.ObjectIDFromHex(mux.Vars(r)["report_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return nil, nil
+	}
+
+	current, err := report.DB.FindByID(ctx, rID)
+	if err != nil {
+		config.ErrorStatus("failed to get report by ID", http.StatusNotFound, w, err)
+		return nil, nil
+	}
+
+	pID, err := primitive.ObjectIDFromHex(current.ProjectID)
+	if err != nil {
+		config.ErrorStatus("report has an invalid project ID", http.StatusInternalServerError, w, err)
+		return nil, nil
+	}
+
+	project, err := report.Members.Projects.FindByID(ctx, pID)
+	if err != nil {
+		config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, err)
+		return nil, nil
+	}
+
+	if archived(w, project) {
+		return nil, nil
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	roles, err := report.Members.roles(ctx, project, userID)
+	if err != nil {
+		config.ErrorStatus("failed to get project roles", http.StatusInternalServerError, w, err)
+		return nil, nil
+	}
+	if len(roles) == 0 {
+		config.ErrorStatus("only project members can do this", http.StatusForbidden, w, nil)
+		return nil, nil
+	}
+	return current, project
+}
+
+// addTags adds names the project defines to a report. Adding labels to an
+// unassigned report also runs the project's label auto-assignment rules
+func (report Report) addTags(w http.ResponseWriter, r *http.Request, tags reportTags) {
+	ctx := r.Context()
+	var details models.ReportTagsDetails
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	current, project := report.tagReport(w, r)
+	if current == nil {
+		return
+	}
+
+	if name := undefinedName(details.Names, tags.defined(project)); name != "" {
+		config.ErrorStatus(fmt.Sprintf("%q is not one of the project's %s", name, tags.field), http.StatusBadRequest, w, nil)
+		return
+	}
+
+	update := bson.M{"$addToSet": bson.M{tags.field: bson.M{"$each": details.Names}}}
+
+	var assignees []string
+	labelRules := project.AutoAssign != nil && project.AutoAssign.Strategy == models.AutoAssignByLabel
+	if tags.field == labelTags.field && labelRules && len(current.AssigneeIDs) == 0 {
+		var err error
+		assignees, err = report.autoAssign(ctx, project, append(current.Labels, details.Names...))
+		if err != nil {
+			config.ErrorStatus("failed to assign report", http.StatusInternalServerError, w, err)
+			return
+		}
+		update["$set"] = bson.M{"assigneeIds": assignees}
+	}
+
+	dbResp, err := report.DB.UpdateByID(ctx, current.ID, update)
+	if err != nil {
+		config.ErrorStatus("the report could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if err := report.Watchers.watch(ctx, assignees, models.WatchReport, current.ID.Hex(), current.ProjectID, models.WatchAssignee); err != nil {
+		config.ErrorStatus("failed to watch report", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// removeTag removes the name in the request path from a report
+func (report Report) removeTag(w http.ResponseWriter, r *http.Request, tags reportTags) {
+	ctx := r.Context()
+
+	name := mux.Vars(r)["name"]
+
+	current, _ := report.tagReport(w, r)
+	if current == nil {
+		return
+	}
+
+	if !slices.Contains(tags.carried(current), name) {
+		config.ErrorStatus(fmt.Sprintf("the report has no %q in its %s", name, tags.field), http.StatusNotFound, w, nil)
+		return
+	}
+
+	dbResp, err := report.DB.UpdateByID(ctx, current.ID, bson.M{"$pull": bson.M{tags.field: name}})
+	if err != nil {
+		config.ErrorStatus("the report could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// maxReportLinks is the most links a single report can have
+const maxReportLinks = 100
+
+// LinkedReport is a link of a report along with what clients show of the linked report
+type LinkedReport struct {
+	models.ReportLink
+	Title    string `json:"title"`
+	State    string `json:"state"`
+	Resolved bool   `json:"resolved"`
+}
+
+// linkedReports loads the reports a report links to, links to reports that no
+// longer exist or the reader can't read are left out
+func (report Report) linkedReports(ctx context.Context, re reader, current *models.Report) ([]LinkedReport, error) {
+	ids := bson.A{}
+	for _, link := range current.Links {
+		if id, err := primitive.ObjectIDFromHex(link.ReportID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	linked := []LinkedReport{}
+	if len(ids) == 0 {
+		return linked, nil
+	}
+
+	reports, err := report.DB.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[string]models.Report{}
+	for _, other := range reports {
+		byID[other.ID.Hex()] = other
+	}
+
+	for _, link := range current.Links {
+		other, ok := byID[link.ReportID]
+		if !ok || !re.canRead(&other) {
+			continue
+		}
+		if !re.privileged(&other) {
+			redact(&other)
+		}
+		linked = append(linked, LinkedReport{ReportLink: link, Title: other.Title, State: other.State, Resolved: other.Resolved})
+	}
+	return linked, nil
+}
+
+// blocks reports whether from blocks to, directly or through a chain of
+// blocking links
+func (report Report) blocks(ctx context.Context, from, to string) (bool, error) {
+	seen := map[string]bool{from: true}
+	next := []string{from}
+
+	for len(next) > 0 {
+		ids := bson.A{}
+		for _, reportID := range next {
+			if id, err := primitive.ObjectIDFromHex(reportID); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		next = nil
+
+		reports, err := report.DB.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return false, err
+		}
+
+		for _, current := range reports {
+			for _, link := range current.Links {
+				if link.Type != models.LinkBlocks || seen[link.ReportID] {
+					continue
+				}
+				if link.ReportID == to {
+					return true, nil
+				}
+				seen[link.ReportID] = true
+				next = append(next, link.ReportID)
+			}
+		}
+	}
+	return false, nil
+}
+
+// ReportLinksHandler returns the links of a report along with the linked reports' title and state
+func (report Report) ReportLinksHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rID, err := primitive.ObjectIDFromHex(mux.Vars(r)["report_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	current, re := report.Members.readableReport(w, r, rID)
+	if current == nil {
+		return
+	}
+
+	linked, err := report.linkedReports(ctx, re, current)
+	if err != nil {
+		config.ErrorStatus("failed to get linked reports", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": linked},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// AddReportLinkHandler links a report to another report of the same project, the
+// inverse link is added to the other report. Project members can link reports
+// as long as blocking links don't form a cycle
+func (report Report) AddReportLinkHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.ReportLinkDetails
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	current, _ := report.tagReport(w, r)
+	if current == nil {
+		return
+	}
+
+	tID, err := primitive.ObjectIDFromHex(details.ReportID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	target, err := report.DB.FindByID(ctx, tID)
+	if err != nil {
+		config.ErrorStatus("failed to get the report to link to", http.StatusNotFound, w, err)
+		return
+	}
+	if target.ID == current.ID {
+		config.ErrorStatus("a report cannot be linked to itself", http.StatusBadRequest, w, nil)
+		return
+	}
+	if target.ProjectID != current.ProjectID {
+		config.ErrorStatus("reports can only be linked within a project", http.StatusBadRequest, w, nil)
+		return
+	}
+
+	if slices.ContainsFunc(current.Links, func(link models.ReportLink) bool { return link.ReportID == details.ReportID }) {
+		config.ErrorStatus("the reports are already linked", http.StatusConflict, w, nil)
+		return
+	}
+	if len(current.Links) >= maxReportLinks || len(target.Links) >= maxReportLinks {
+		config.ErrorStatus(fmt.Sprintf("reports can have at most %d links", maxReportLinks), http.StatusBadRequest, w, nil)
+		return
+	}
+
+	// a blocking link from one report to another is only allowed when the
+	// other report doesn't already block the first one
+	blocker, blocked := current.ID.Hex(), target.ID.Hex()
+	if details.Type == models.LinkBlockedBy {
+		blocker, blocked = blocked, blocker
+	}
+	if details.Type == models.LinkBlocks || details.Type == models.LinkBlockedBy {
+		cycle, err := report.blocks(ctx, blocked, blocker)
+		if err != nil {
+			config.ErrorStatus("failed to check blocking links", http.StatusInternalServerError, w, err)
+			return
+		}
+		if cycle {
+			config.ErrorStatus("the link would create a cycle of blocking reports", http.StatusConflict, w, nil)
+			return
+		}
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	now := time.Now().UTC()
+
+	link := models.ReportLink{Type: details.Type, ReportID: target.ID.Hex(), CreatedBy: userID, CreatedAt: now}
+	inverse := models.ReportLink{Type: models.LinkInverses[details.Type], ReportID: current.ID.Hex(), CreatedBy: userID, CreatedAt: now}
+
+	dbResp, err := report.DB.UpdateByID(ctx, current.ID, bson.M{"$push": bson.M{"links": link}})
+	if err != nil {
+		config.ErrorStatus("the report could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if _, err := report.DB.UpdateByID(ctx, target.ID, bson.M{"$push": bson.M{"links": inverse}}); err != nil {
+		config.ErrorStatus("the linked report could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// RemoveReportLinkHandler removes the link between two reports from both of them
+func (report Report) RemoveReportLinkHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	linkedID := mux.Vars(r)["linked_id"]
+
+	current, _ := report.tagReport(w, r)
+	if current == nil {
+		return
+	}
+
+	if !slices.ContainsFunc(current.Links, func(link models.ReportLink) bool { return link.ReportID == linkedID }) {
+		config.ErrorStatus("the reports are not linked", http.StatusNotFound, w, nil)
+		return
+	}
+
+	dbResp, err := report.DB.UpdateByID(ctx, current.ID, bson.M{"$pull": bson.M{"links": bson.M{"reportId": linkedID}}})
+	if err != nil {
+		config.ErrorStatus("the report could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// the linked ID comes from the report's own links so it is a valid ObjectID
+	lID, _ := primitive.ObjectIDFromHex(linkedID)
+	if err := report.unlink(ctx, bson.M{"_id": lID}, current.ID.Hex()); err != nil {
+		config.ErrorStatus("the linked report could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// unlink removes the links to a report from the reports matching filter
+func (report Report) unlink(ctx context.Context, filter bson.M, reportID string) error {
+	filter["links.reportId"] = reportID
+	_, err := report.DB.UpdateMany(ctx, filter, bson.M{"$pull": bson.M{"links": bson.M{"reportId": reportID}}})
+	return err
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+func TestBlocks(t *testing.T) {
+	ctx := context.Background()
+
+	conf := &config.Config{Driver: "sqlite", URL: filepath.Join(t.TempDir(), "test.db")}
+	client, err := databases.NewClient(conf)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	db := databases.NewDatabase(conf, client)
+	if err := db.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("failed to create indexes: %v", err)
+	}
+
+	// a blocks b and b blocks c, d only relates to a. Links are stored on
+	// both reports with the inverse type on the other one
+	a, b, c, d := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	link := func(linkType string, to primitive.ObjectID) models.ReportLink {
+		return models.ReportLink{Type: linkType, ReportID: to.Hex()}
+	}
+	reports := databases.NewReportDatabase(db)
+	for _, seed := range []models.Report{
+		{ID: a, Links: []models.ReportLink{link(models.LinkBlocks, b), link(models.LinkRelatesTo, d)}},
+		{ID: b, Links: []models.ReportLink{link(models.LinkBlockedBy, a), link(models.LinkBlocks, c)}},
+		{ID: c, Links: []models.ReportLink{link(models.LinkBlockedBy, b)}},
+		{ID: d, Links: []models.ReportLink{link(models.LinkRelatesTo, a)}},
+	} {
+		if _, err := reports.InsertOne(ctx, &seed); err != nil {
+			t.Fatalf("failed to insert report: %v", err)
+		}
+	}
+	report := Report{DB: reports}
+
+	// a new "x blocks y" link closes a cycle when y already blocks x
+	cases := []struct {
+		name     string
+		from, to primitive.ObjectID
+		blocks   bool
+	}{
+		{name: "direct", from: a, to: b, blocks: true},
+		{name: "indirect", from: a, to: c, blocks: true},
+		{name: "against the links", from: c, to: a},
+		{name: "only related", from: a, to: d},
+		{name: "unlinked", from: d, to: c},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			blocks, err := report.blocks(ctx, tc.from.Hex(), tc.to.Hex())
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if blocks != tc.blocks {
+				t.Errorf("blocks is %v, want %v", blocks, tc.blocks)
+			}
+		})
+	}
+}
@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// Membership manages who belongs to which project. The memberships collection
+// is the source of truth, User.ProjectIDs and Project.AdminsIDs are copies of
+// it that are rebuilt whenever a membership changes. Report assignees must
+// be members so they are dropped along with the membership
+type Membership struct {
+	DB       databases.MembershipDatabase
+	Users    databases.UserDatabase
+	Projects databases.ProjectDatabase
+	Reports  databases.ReportDatabase
+}
+
+// ProjectMembersHandler returns the members of a project with their roles
+func (membership Membership) ProjectMembersHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := mux.Vars(r)["project_id"]
+
+	if membership.visibleProject(w, r, projectID) == nil {
+		return
+	}
+
+	memberships, err := membership.DB.Find(ctx, bson.M{"projectId": projectID}, databases.FindOptions{Sort: bson.D{{Key: "joinedAt", Value: 1}}})
+	if err != nil {
+		config.ErrorStatus("failed to get project members", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	userIDs := []primitive.ObjectID{}
+	for _, m := range memberships {
+		if uID, err := primitive.ObjectIDFromHex(m.UserID); err == nil {
+			userIDs = append(userIDs, uID)
+		}
+	}
+
+	users, err := membership.Users.Find(ctx, bson.M{"_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		config.ErrorStatus("failed to get project members", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	usersByID := map[string]models.User{}
+	for _, u := range users {
+		usersByID[u.ID.Hex()] = u
+	}
+
+	members := []models.ProjectMember{}
+	for _, m := range memberships {
+		if u, ok := usersByID[m.UserID]; ok {
+			members = append(members, models.ProjectMember{User: u, Role: m.Role, JoinedAt: m.JoinedAt})
+		}
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": members},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// UserProjectsHandler returns the projects a user is a member of with their role
+// in each, archived projects are only included with archived=true and private
+// ones only to their members
+func (membership Membership) UserProjectsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := mux.Vars(r)["user_id"]
+
+	memberships, err := membership.DB.Find(ctx, bson.M{"userId": userID}, databases.FindOptions{Sort: bson.D{{Key: "joinedAt", Value: 1}}})
+	if err != nil {
+		config.ErrorStatus("failed to get user projects", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	projectIDs := []primitive.ObjectID{}
+	for _, m := range memberships {
+		if pID, err := primitive.ObjectIDFromHex(m.ProjectID); err == nil {
+			projectIDs = append(projectIDs, pID)
+		}
+	}
+
+	filter := bson.M{"_id": bson.M{"$in": projectIDs}}
+	if r.URL.Query().Get("archived") != "true" {
+		filter["archived"] = bson.M{"$ne": true}
+	}
+
+	projects, err := membership.Projects.Find(ctx, filter)
+	if err != nil {
+		config.ErrorStatus("failed to get user projects", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// private projects are left out unless the caller is a member too
+	re, err := membership.reader(ctx)
+	if err != nil {
+		config.ErrorStatus("failed to check project visibility", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	projectsByID := map[string]models.Project{}
+	for _, p := range projects {
+		if !slices.Contains(re.hidden, p.ID.Hex()) {
+			projectsByID[p.ID.Hex()] = p
+		}
+	}
+
+	result := []models.MemberProject{}
+	for _, m := range memberships {
+		if p, ok := projectsByID[m.ProjectID]; ok {
+			result = append(result, models.MemberProject{Project: p, Role: m.Role, JoinedAt: m.JoinedAt})
+		}
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": result},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// AddMemberHandler adds a user to a project, only project admins can add members
+func (membership Membership) AddMemberHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.MembershipDetails
+
+	projectID := mux.Vars(r)["project_id"]
+
+	if projectAdmin(ctx, w, r, membership.Projects, projectID) == nil {
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	uID, err := primitive.ObjectIDFromHex(details.UserID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	exists, err := membership.Users.Exists(ctx, bson.M{"_id": uID})
+	if err != nil {
+		config.ErrorStatus("failed to get user by ID", http.StatusInternalServerError, w, err)
+		return
+	}
+	if !exists {
+		config.ErrorStatus("User not found", http.StatusNotFound, w, nil)
+		return
+	}
+
+	result, err := membership.add(ctx, projectID, details.UserID, details.Role)
+	if errors.Is(err, databases.ErrDuplicateKey) {
+		config.ErrorStatus("user is already a member of the project", http.StatusConflict, w, err)
+		return
+	}
+	if err != nil {
+		config.ErrorStatus("failed to add member", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusCreated,
+			Message: "success",
+			Data:    map[string]any{"result": result},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// UpdateMemberHandler changes the role of a project member, the owner's role cannot be changed
+func (membership Membership) UpdateMemberHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var newDetails models.MembershipUpdateDetails
+
+	projectID := mux.Vars(r)["project_id"]
+	userID := mux.Vars(r)["user_id"]
+
+	project := projectAdmin(ctx, w, r, membership.Projects, projectID)
+	if project == nil {
+		return
+	}
+
+	if project.OwnerID == userID {
+		config.ErrorStatus("the project owner's role cannot be changed", http.StatusBadRequest, w, nil)
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&newDetails); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&newDetails); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	dbResp, err := membership.DB.UpdateOne(
+		ctx,
+		bson.M{"projectId": projectID, "userId": userID},
+		bson.M{"$set": bson.M{"role": newDetails.Role}},
+	)
+
+	if err != nil {
+		config.ErrorStatus("the member could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("Member not found", http.StatusNotFound, w, nil)
+		return
+	}
+
+	if err := membership.sync(ctx, projectID, userID); err != nil {
+		config.ErrorStatus("failed to update member", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// promotions and demotions are recorded in the project history
+	if wasAdmin := slices.Contains(project.AdminsIDs, userID); wasAdmin != (newDetails.Role == models.RoleAdmin) {
+		change := models.ProjectChange{Type: models.ProjectAdminAdded, UserID: userID, At: time.Now().UTC()}
+		if wasAdmin {
+			change.Type = models.ProjectAdminRemoved
+		}
+		change.By, _ = api.UserIDFromContext(ctx)
+
+		if err := membership.record(ctx, project.ID, change); err != nil {
+			config.ErrorStatus("failed to record project history", http.StatusInternalServerError, w, err)
+			return
+		}
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// RemoveMemberHandler removes a user from a project. Admins can remove anyone
+// but the owner and any member can remove themselves
+func (membership Membership) RemoveMemberHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := mux.Vars(r)["project_id"]
+	userID := mux.Vars(r)["user_id"]
+
+	pID, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	project, err := membership.Projects.FindByID(ctx, pID)
+	if err != nil {
+		config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	callerID, _ := api.UserIDFromContext(r.Context())
+	if callerID != userID && !isProjectAdmin(project, callerID) {
+		config.ErrorStatus("only project admins can remove other members", http.StatusForbidden, w, nil)
+		return
+	}
+
+	if project.OwnerID == userID {
+		config.ErrorStatus("the project owner cannot be removed", http.StatusBadRequest, w, nil)
+		return
+	}
+
+	dbResp, err := membership.DB.DeleteOne(ctx, bson.M{"projectId": projectID, "userId": userID})
+	if err != nil {
+		config.ErrorStatus("failed to remove member", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if dbResp.DeletedCount == 0 {
+		config.ErrorStatus("Member not found", http.StatusNotFound, w, nil)
+		return
+	}
+
+	if err := membership.sync(ctx, projectID, userID); err != nil {
+		config.ErrorStatus("failed to remove member", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if slices.Contains(project.AdminsIDs, userID) {
+		change := models.ProjectChange{Type: models.ProjectAdminRemoved, UserID: userID, By: callerID, At: time.Now().UTC()}
+		if err := membership.record(ctx, project.ID, change); err != nil {
+			config.ErrorStatus("failed to record project history", http.StatusInternalServerError, w, err)
+			return
+		}
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// add creates a membership and updates the denormalized arrays
+func (membership Membership) add(ctx context.Context, projectID, userID, role string) (*models.Membership, error) {
+	newMembership := models.Membership{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		ProjectID: projectID,
+		Role:      role,
+		JoinedAt:  time.Now().UTC(),
+	}
+
+	if _, err := membership.DB.InsertOne(ctx, &newMembership); err != nil {
+		return nil, err
+	}
+
+	return &newMembership, membership.sync(ctx, projectID, userID)
+}
+
+// sync rebuilds User.ProjectIDs and Project.AdminsIDs for one user and project
+// from the memberships collection, running it more than once is harmless
+func (membership Membership) sync(ctx context.Context, projectID, userID string) error {
+	current, err := membership.DB.FindOne(ctx, bson.M{"projectId": projectID, "userId": userID})
+	if err != nil && !errors.Is(err, databases.ErrNotFound) {
+		return err
+	}
+
+	userUpdate := bson.M{"$pull": bson.M{"projectIds": projectID}}
+	projectUpdate := bson.M{"$pull": bson.M{"adminIds": userID}}
+	if current != nil {
+		userUpdate = bson.M{"$addToSet": bson.M{"projectIds": projectID}}
+		if current.Role == models.RoleAdmin {
+			projectUpdate = bson.M{"$addToSet": bson.M{"adminIds": userID}}
+		}
+	}
+
+	if uID, err := primitive.ObjectIDFromHex(userID); err == nil {
+		if _, err := membership.Users.UpdateByID(ctx, uID, userUpdate); err != nil {
+			return err
+		}
+	}
+
+	if pID, err := primitive.ObjectIDFromHex(projectID); err == nil {
+		if _, err := membership.Projects.UpdateByID(ctx, pID, projectUpdate); err != nil {
+			return err
+		}
+	}
+
+	if current == nil {
+		filter := bson.M{"projectId": projectID, "assigneeIds": userID}
+		if _, err := membership.Reports.UpdateMany(ctx, filter, bson.M{"$pull": bson.M{"assigneeIds": userID}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeProject drops every membership of a deleted project
+func (membership Membership) removeProject(ctx context.Context, projectID string) error {
+	if _, err := membership.DB.DeleteMany(ctx, bson.M{"projectId": projectID}); err != nil {
+		return err
+	}
+
+	_, err := membership.Users.UpdateMany(ctx, bson.M{"projectIds": projectID}, bson.M{"$pull": bson.M{"projectIds": projectID}})
+	return err
+}
+
+// removeUser drops every membership of a deleted user
+func (membership Membership) removeUser(ctx context.Context, userID string) error {
+	if _, err := membership.DB.DeleteMany(ctx, bson.M{"userId": userID}); err != nil {
+		return err
+	}
+
+	if _, err := membership.Projects.UpdateMany(ctx, bson.M{"adminIds": userID}, bson.M{"$pull": bson.M{"adminIds": userID}}); err != nil {
+		return err
+	}
+
+	_, err := membership.Reports.UpdateMany(ctx, bson.M{"assigneeIds": userID}, bson.M{"$pull": bson.M{"assigneeIds": userID}})
+	return err
+}
+
+// roles returns every role a user holds in a project, owners and admins also
+// hold the roles below their own
+func (membership Membership) roles(ctx context.Context, project *models.Project, userID string) ([]string, error) {
+	switch {
+	case userID == "":
+		return nil, nil
+	case project.OwnerID == userID:
+		return []string{models.RoleOwner, models.RoleAdmin, models.RoleMember}, nil
+	case slices.Contains(project.AdminsIDs, userID):
+		return []string{models.RoleAdmin, models.RoleMember}, nil
+	}
+
+	exists, err := membership.DB.Exists(ctx, bson.M{"projectId": project.ID.Hex(), "userId": userID})
+	if err != nil || !exists {
+		return nil, err
+	}
+	return []string{models.RoleMember}, nil
+}
+
+// isProjectAdmin reports whether a user owns or administers a project
+func isProjectAdmin(project *models.Project, userID string) bool {
+	return userID != "" && (project.OwnerID == userID || slices.Contains(project.AdminsIDs, userID))
+}
+
+// projectAdmin loads a project and checks the authenticated user owns or
+// administers it. When they don't, or the project can't be loaded, the error
+// response is written and nil is returned
+func projectAdmin(ctx context.Context, w http.ResponseWriter, r *http.Request, projects databases.ProjectDatabase, projectID string) *models.Project {
+	pID, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return nil
+	}
+
+	project, err := projects.FindByID(ctx, pID)
+	if err != nil {
+		config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, err)
+		return nil
+	}
+
+	userID, _ := api.UserIDFromContext(r.Context())
+	if !isProjectAdmin(project, userID) {
+		config.ErrorStatus("only project admins can do this", http.StatusForbidden, w, nil)
+		return nil
+	}
+	return project
+}
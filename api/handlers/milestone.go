@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+func milestoneNames(project *models.Project) []string {
+	names := []string{}
+	for _, milestone := range project.Milestones {
+		names = append(names, milestone.Name)
+	}
+	return names
+}
+
+// validateVersions checks the versions a report names are milestones of its project
+func validateVersions(project *models.Project, affected []string, fix string) error {
+	names := milestoneNames(project)
+	if name := undefinedName(affected, names); name != "" {
+		return fmt.Errorf("%q is not one of the project's milestones", name)
+	}
+	if fix != "" && !slices.Contains(names, fix) {
+		return fmt.Errorf("%q is not one of the project's milestones", fix)
+	}
+	return nil
+}
+
+// UpdateMilestonesHandler replaces the milestones of a project, only project admins can change them.
+// Milestones that are dropped are removed from the versions of every report of the project
+func (project Project) UpdateMilestonesHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.MilestonesDetails
+
+	projectID := mux.Vars(r)["project_id"]
+
+	current := projectAdmin(ctx, w, r, project.DB, projectID)
+	if current == nil || archived(w, current) {
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	if details.Milestones == nil {
+		details.Milestones = []models.Milestone{}
+	}
+
+	dbResp, err := project.DB.UpdateByID(ctx, current.ID, bson.M{"$set": bson.M{"milestones": details.Milestones}})
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	kept := milestoneNames(&models.Project{Milestones: details.Milestones})
+	if err := project.dropVersions(ctx, projectID, milestoneNames(current), kept); err != nil {
+		config.ErrorStatus("failed to remove versions from reports", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// dropVersions removes the milestones in before but not in after from the
+// affected and fix versions of every report of a project
+func (project Project) dropVersions(ctx context.Context, projectID string, before, after []string) error {
+	removed := []string{}
+	for _, name := range before {
+		if !slices.Contains(after, name) {
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	reports := project.Members.Reports
+	filter := bson.M{"projectId": projectID, "affectedVersions": bson.M{"$in": removed}}
+	if _, err := reports.UpdateMany(ctx, filter, bson.M{"$pull": bson.M{"affectedVersions": bson.M{"$in": removed}}}); err != nil {
+		return err
+	}
+
+	filter = bson.M{"projectId": projectID, "fixVersion": bson.M{"$in": removed}}
+	_, err := reports.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"fixVersion": ""}})
+	return err
+}
+
+// MilestoneProgressHandler counts the open and resolved reports to be fixed in a milestone
+func (project Project) MilestoneProgressHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := mux.Vars(r)["project_id"]
+	name := mux.Vars(r)["name"]
+
+	current := project.Members.visibleProject(w, r, projectID)
+	if current == nil {
+		return
+	}
+
+	i := slices.IndexFunc(current.Milestones, func(milestone models.Milestone) bool { return milestone.Name == name })
+	if i < 0 {
+		config.ErrorStatus(fmt.Sprintf("%q is not one of the project's milestones", name), http.StatusNotFound, w, nil)
+		return
+	}
+
+	progress := models.MilestoneProgress{Milestone: current.Milestones[i]}
+	reports := project.Members.Reports
+
+	var err error
+	progress.Open, err = reports.Count(ctx, bson.M{"projectId": projectID, "fixVersion": name, "resolved": false})
+	if err != nil {
+		config.ErrorStatus("failed to count reports", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	progress.Resolved, err = reports.Count(ctx, bson.M{"projectId": projectID, "fixVersion": name, "resolved": true})
+	if err != nil {
+		config.ErrorStatus("failed to count reports", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	progress.Affected, err = reports.Count(ctx, bson.M{"projectId": projectID, "affectedVersions": name})
+	if err != nil {
+		config.ErrorStatus("failed to count reports", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if total := progress.Open + progress.Resolved; total > 0 {
+		progress.Percent = float64(progress.Resolved*10000/total) / 100
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": progress},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// record adds a change to a project's history
+func (membership Membership) record(ctx context.Context, pID primitive.ObjectID, change models.ProjectChange) error {
+	_, err := membership.Projects.UpdateByID(ctx, pID, bson.M{"$push": bson.M{"history": change}})
+	return err
+}
+
+// setRole changes the role of a project member and updates the denormalized
+// arrays, reporting false when the user isn't a member
+func (membership Membership) setRole(ctx context.Context, projectID, userID, role string) (bool, error) {
+	dbResp, err := membership.DB.UpdateOne(ctx, bson.M{"projectId": projectID, "userId": userID}, bson.M{"$set": bson.M{"role": role}})
+	if err != nil || dbResp.MatchedCount == 0 {
+		return false, err
+	}
+	return true, membership.sync(ctx, projectID, userID)
+}
+
+// AddAdminHandler makes a member of a project one of its admins, only project
+// admins can add admins. People outside the project have to be invited first
+func (membership Membership) AddAdminHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := mux.Vars(r)["project_id"]
+	userID := mux.Vars(r)["user_id"]
+
+	project := projectAdmin(ctx, w, r, membership.Projects, projectID)
+	if project == nil {
+		return
+	}
+
+	if isProjectAdmin(project, userID) {
+		config.ErrorStatus("user is already an admin of the project", http.StatusConflict, w, nil)
+		return
+	}
+
+	member, err := membership.setRole(ctx, projectID, userID, models.RoleAdmin)
+	if err != nil {
+		config.ErrorStatus("the member could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+	if !member {
+		config.ErrorStatus("only project members can be made admins", http.StatusNotFound, w, nil)
+		return
+	}
+
+	callerID, _ := api.UserIDFromContext(ctx)
+	change := models.ProjectChange{Type: models.ProjectAdminAdded, UserID: userID, By: callerID, At: time.Now().UTC()}
+	if err := membership.record(ctx, project.ID, change); err != nil {
+		config.ErrorStatus("failed to record project history", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": change},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// RemoveAdminHandler makes an admin of a project a plain member again. The
+// owner can remove any admin and admins can step down themselves, the owner
+// stays an admin until they transfer the project
+func (membership Membership) RemoveAdminHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := mux.Vars(r)["project_id"]
+	userID := mux.Vars(r)["user_id"]
+
+	project := projectAdmin(ctx, w, r, membership.Projects, projectID)
+	if project == nil {
+		return
+	}
+
+	callerID, _ := api.UserIDFromContext(ctx)
+	if callerID != userID && callerID != project.OwnerID {
+		config.ErrorStatus("only the project owner can remove other admins", http.StatusForbidden, w, nil)
+		return
+	}
+
+	if project.OwnerID == userID {
+		config.ErrorStatus("the project owner cannot be removed as an admin, transfer the project first", http.StatusBadRequest, w, nil)
+		return
+	}
+	if !slices.Contains(project.AdminsIDs, userID) {
+		config.ErrorStatus("Admin not found", http.StatusNotFound, w, nil)
+		return
+	}
+
+	if _, err := membership.setRole(ctx, projectID, userID, models.RoleMember); err != nil {
+		config.ErrorStatus("the member could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	change := models.ProjectChange{Type: models.ProjectAdminRemoved, UserID: userID, By: callerID, At: time.Now().UTC()}
+	if err := membership.record(ctx, project.ID, change); err != nil {
+		config.ErrorStatus("failed to record project history", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": change},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// TransferOwnershipHandler offers a project to one of its members, only the
+// owner can offer it. Nothing changes until the member accepts, offering it
+// again replaces the earlier offer
+func (membership Membership) TransferOwnershipHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.OwnershipTransferDetails
+
+	projectID := mux.Vars(r)["project_id"]
+
+	project := projectAdmin(ctx, w, r, membership.Projects, projectID)
+	if project == nil {
+		return
+	}
+
+	callerID, _ := api.UserIDFromContext(ctx)
+	if callerID != project.OwnerID {
+		config.ErrorStatus("only the project owner can transfer the project", http.StatusForbidden, w, nil)
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	if details.UserID == project.OwnerID {
+		config.ErrorStatus("user already owns the project", http.StatusBadRequest, w, nil)
+		return
+	}
+
+	member, err := membership.DB.Exists(ctx, bson.M{"projectId": projectID, "userId": details.UserID})
+	if err != nil {
+		config.ErrorStatus("failed to check project members", http.StatusInternalServerError, w, err)
+		return
+	}
+	if !member {
+		config.ErrorStatus("projects can only be transferred to their members", http.StatusNotFound, w, nil)
+		return
+	}
+
+	transfer := models.OwnershipTransfer{UserID: details.UserID, RequestedBy: callerID, RequestedAt: time.Now().UTC()}
+
+	// only offer it if the project didn't change hands since we read it
+	filter := bson.M{"_id": project.ID, "ownerId": callerID}
+	dbResp, err := membership.Projects.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"transfer": transfer}})
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("the project has changed owner", http.StatusConflict, w, nil)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": transfer},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// CancelTransferHandler withdraws the offer of a project, only the owner can withdraw it
+func (membership Membership) CancelTransferHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	project := projectAdmin(ctx, w, r, membership.Projects, mux.Vars(r)["project_id"])
+	if project == nil {
+		return
+	}
+
+	callerID, _ := api.UserIDFromContext(ctx)
+	if callerID != project.OwnerID {
+		config.ErrorStatus("only the project owner can cancel a transfer", http.StatusForbidden, w, nil)
+		return
+	}
+
+	membership.dropTransfer(w, r, project, "")
+}
+
+// DeclineTransferHandler turns down the offer of a project to the authenticated user
+func (membership Membership) DeclineTransferHandler(w http.ResponseWriter, r *http.Request) {
+	project := membership.transferee(w, r)
+	if project == nil {
+		return
+	}
+
+	membership.dropTransfer(w, r, project, project.Transfer.UserID)
+}
+
+// dropTransfer removes the offer of a project, only when it is still made to
+// userID if one is given
+func (membership Membership) dropTransfer(w http.ResponseWriter, r *http.Request, project *models.Project, userID string) {
+	filter := bson.M{"_id": project.ID, "transfer": bson.M{"$exists": true}}
+	if userID != "" {
+		filter["transfer.userId"] = userID
+	}
+
+	dbResp, err := membership.Projects.UpdateOne(r.Context(), filter, bson.M{"$unset": bson.M{"transfer": ""}})
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("Transfer not found", http.StatusNotFound, w, nil)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// AcceptTransferHandler makes the authenticated user the owner of the project
+// offered to them. The previous owner stays on as an admin, the project has
+// an owner throughout since ownership moves in a single update
+func (membership Membership) AcceptTransferHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	project := membership.transferee(w, r)
+	if project == nil {
+		return
+	}
+
+	projectID := project.ID.Hex()
+	userID, previous := project.Transfer.UserID, project.OwnerID
+
+	// members who left since the offer was made can't take the project over
+	member, err := membership.DB.Exists(ctx, bson.M{"projectId": projectID, "userId": userID})
+	if err != nil {
+		config.ErrorStatus("failed to check project members", http.StatusInternalServerError, w, err)
+		return
+	}
+	if !member {
+		config.ErrorStatus("only project members can take over the project", http.StatusConflict, w, nil)
+		return
+	}
+
+	change := models.ProjectChange{Type: models.ProjectOwnershipTransferred, UserID: userID, From: previous, By: userID, At: time.Now().UTC()}
+
+	// only take it over if the offer still stands and nobody else took it
+	filter := bson.M{"_id": project.ID, "ownerId": previous, "transfer.userId": userID}
+	update := bson.M{
+		"$set":   bson.M{"ownerId": userID},
+		"$unset": bson.M{"transfer": ""},
+		"$push":  bson.M{"history": change},
+	}
+
+	dbResp, err := membership.Projects.UpdateOne(ctx, filter, update)
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("the transfer is no longer on offer", http.StatusConflict, w, nil)
+		return
+	}
+
+	if _, err := membership.setRole(ctx, projectID, userID, models.RoleOwner); err != nil {
+		config.ErrorStatus("failed to update the new owner", http.StatusInternalServerError, w, err)
+		return
+	}
+	if _, err := membership.setRole(ctx, projectID, previous, models.RoleAdmin); err != nil {
+		config.ErrorStatus("failed to update the previous owner", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": change},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// transferee loads a project and checks it is on offer to the authenticated
+// user. When it isn't the error response is written and nil is returned
+func (membership Membership) transferee(w http.ResponseWriter, r *http.Request) *models.Project {
+	ctx := r.Context()
+
+	pID, err := primitive.ObjectIDFromHex(mux.Vars(r)["project_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return nil
+	}
+
+	project, err := membership.Projects.FindByID(ctx, pID)
+	if err != nil {
+		config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, err)
+		return nil
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	if project.Transfer == nil || project.Transfer.UserID != userID {
+		config.ErrorStatus("Transfer not found", http.StatusNotFound, w, nil)
+		return nil
+	}
+	return project
+}
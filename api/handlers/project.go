@@ -1,15 +1,15 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
-	"time"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
+	"github.com/BugBridge/bugbridge-api/api"
 	"github.com/BugBridge/bugbridge-api/config"
 	"github.com/BugBridge/bugbridge-api/databases"
 	"github.com/BugBridge/bugbridge-api/models"
@@ -17,24 +17,20 @@ import (
 )
 
 type Project struct {
-	DB databases.ProjectDatabase
+	DB          databases.ProjectDatabase
+	Members     Membership
+	Watchers    Subscription
+	Invitations databases.InvitationDatabase
+	Templates   Templates
+	Comments    databases.CommentDatabase
 }
 
 // TODO: add delete and update functionality
 
 // ProjectByIDHandler returns a project by a given ID
 func (project Project) ProjectByObjectIDHandler(w http.ResponseWriter, r *http.Request) {
-	projectID := mux.Vars(r)["project_id"]
-
-	pID, err := primitive.ObjectIDFromHex(projectID)
-	if err != nil {
-		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
-		return
-	}
-
-	dbResp, err := project.DB.FindOne(context.Background(), bson.M{"_id": pID})
-	if err != nil {
-		config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, err)
+	dbResp := project.Members.visibleProject(w, r, mux.Vars(r)["project_id"])
+	if dbResp == nil {
 		return
 	}
 
@@ -57,9 +53,8 @@ func (project Project) ProjectByObjectIDHandler(w http.ResponseWriter, r *http.R
 
 // Create a new project
 func (project Project) NewProjectHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx := r.Context()
 	var details models.ProjectDetails // Json data will represent the report details model
-	defer cancel()
 
 	// validate the request body
 	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
@@ -75,6 +70,11 @@ func (project Project) NewProjectHandler(w http.ResponseWriter, r *http.Request)
 
 	// TODO: add validation to title / description length
 
+	workflow := defaultWorkflow()
+	if details.Visibility == "" {
+		details.Visibility = models.ProjectPublic
+	}
+
 	newProject := models.Project{
 		ID:        primitive.NewObjectID(),
 		Name:      details.Name,
@@ -82,14 +82,37 @@ func (project Project) NewProjectHandler(w http.ResponseWriter, r *http.Request)
 		Template:  details.Template,
 		OwnerID:   details.OwnerID,
 		AdminsIDs: []string{},
+		Workflow:  &workflow,
+
+		Visibility: details.Visibility,
+
+		Labels:       []models.Label{},
+		Components:   []models.Component{},
+		CustomFields: []models.CustomField{},
+		Milestones:   []models.Milestone{},
+
+		History: []models.ProjectChange{},
 	}
 
-	result, err := project.DB.InsertOne(ctx, newProject)
+	result, err := project.DB.InsertOne(ctx, &newProject)
 	if err != nil {
 		config.ErrorStatus("failed to insert project", http.StatusBadRequest, w, err)
 		return
 	}
 
+	// the owner is the first member of every project
+	if _, err := project.Members.add(ctx, newProject.ID.Hex(), newProject.OwnerID, models.RoleOwner); err != nil {
+		config.ErrorStatus("failed to add project owner as a member", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// the template the project starts with is its first version
+	userID, _ := api.UserIDFromContext(ctx)
+	if _, err := project.Templates.version(ctx, &newProject, userID); err != nil {
+		config.ErrorStatus("failed to record template version", http.StatusInternalServerError, w, err)
+		return
+	}
+
 	b, err := json.Marshal(
 		models.DataResponse{
 			Status:  http.StatusCreated,
@@ -107,20 +130,22 @@ func (project Project) NewProjectHandler(w http.ResponseWriter, r *http.Request)
 	w.Write(b)
 }
 
-// UpdateProjectHandler updates the attributes of a project
+// UpdateProjectHandler updates the attributes of a project, only project admins
+// can update it and archived projects can't be updated
 func (project Project) UpdateProjectHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx := r.Context()
 	var newDetails models.ProjectUpdateDetails
-	defer cancel()
 
 	projectID := mux.Vars(r)["project_id"]
 
-	pID, err := primitive.ObjectIDFromHex(projectID)
-
-	if err != nil {
-		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+	current := projectAdmin(ctx, w, r, project.DB, projectID)
+	if current == nil {
 		return
 	}
+	if archived(w, current) {
+		return
+	}
+	pID := current.ID
 
 	// validate the request body
 	if err := json.NewDecoder(r.Body).Decode(&newDetails); err != nil {
@@ -136,9 +161,22 @@ func (project Project) UpdateProjectHandler(w http.ResponseWriter, r *http.Reque
 
 	update := util.BuildUpdate(newDetails)
 
-	dbResp, err := project.DB.UpdateOne(
+	// template changes are recorded as a new version, projects from before
+	// versioning have the template they're replacing recorded first
+	templateChanged := false
+	for key := range update {
+		templateChanged = templateChanged || strings.HasPrefix(key, "template.")
+	}
+	if templateChanged && current.TemplateVersion == 0 {
+		if _, err := project.Templates.version(ctx, current, ""); err != nil {
+			config.ErrorStatus("failed to record template version", http.StatusInternalServerError, w, err)
+			return
+		}
+	}
+
+	dbResp, err := project.DB.UpdateByID(
 		ctx,
-		bson.M{"_id": pID},
+		pID,
 		bson.M{"$set": update},
 	)
 
@@ -147,6 +185,25 @@ func (project Project) UpdateProjectHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("Project not found", http.StatusNotFound, w, nil)
+		return
+	}
+
+	if templateChanged {
+		updated, err := project.DB.FindByID(ctx, pID)
+		if err != nil {
+			config.ErrorStatus("Project not found", http.StatusNotFound, w, err)
+			return
+		}
+
+		userID, _ := api.UserIDFromContext(ctx)
+		if _, err := project.Templates.version(ctx, updated, userID); err != nil {
+			config.ErrorStatus("failed to record template version", http.StatusInternalServerError, w, err)
+			return
+		}
+	}
+
 	b, err := json.Marshal(
 		models.DataResponse{
 			Status:  http.StatusOK,
@@ -164,30 +221,55 @@ func (project Project) UpdateProjectHandler(w http.ResponseWriter, r *http.Reque
 	w.Write(b)
 }
 
+// DeleteProjectByIdHandler deletes a project along with its members, watchers,
+// invitations and template versions, only its owner can delete it
 func (project Project) DeleteProjectByIdHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	projectID := mux.Vars(r)["project_id"]
 
-	uID, err := primitive.ObjectIDFromHex(projectID)
+	current := projectAdmin(ctx, w, r, project.DB, projectID)
+	if current == nil {
+		return
+	}
 
-	if err != nil {
-		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+	if callerID, _ := api.UserIDFromContext(ctx); callerID != current.OwnerID {
+		config.ErrorStatus("only the project owner can delete the project", http.StatusForbidden, w, nil)
 		return
 	}
+	uID := current.ID
 
-	dbResp, err := project.DB.DeleteOne(ctx, bson.M{"_id": uID})
+	dbResp, err := project.DB.DeleteByID(ctx, uID)
 	if err != nil {
 		config.ErrorStatus("failed to delete project", http.StatusNotFound, w, err)
 		return
 	}
 
-	if dbResp.Dr.DeletedCount == 0 {
+	if dbResp.DeletedCount == 0 {
 		config.ErrorStatus("Project not found", http.StatusNotFound, w, nil)
 		return
 	}
 
+	if err := project.Members.removeProject(ctx, projectID); err != nil {
+		config.ErrorStatus("failed to remove project members", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if _, err := project.Watchers.DB.DeleteMany(ctx, bson.M{"projectId": projectID}); err != nil {
+		config.ErrorStatus("failed to remove project watchers", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if _, err := project.Invitations.DeleteMany(ctx, bson.M{"projectId": projectID}); err != nil {
+		config.ErrorStatus("failed to remove project invitations", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if _, err := project.Templates.DB.DeleteMany(ctx, bson.M{"projectId": projectID}); err != nil {
+		config.ErrorStatus("failed to remove project template versions", http.StatusInternalServerError, w, err)
+		return
+	}
+
 	b, err := json.Marshal(
 		models.DataResponse{
 			Status:  http.StatusOK,
@@ -1,9 +1,14 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"fmt"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -17,7 +22,14 @@ import (
 )
 
 type Report struct {
-	DB databases.ReportDatabase
+	DB          databases.ReportDatabase
+	Members     Membership
+	Attachments Attachment
+	Watchers    Subscription
+	Votes       Vote
+	Reputation  Reputation
+	Disclosures Disclosures
+	Templates   Templates
 }
 
 // TODO: add delete and update functionality
@@ -32,11 +44,87 @@ func (report Report) ReportByObjectIDHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	dbResp, err := report.DB.FindOne(context.Background(), bson.M{"_id": rID})
+	dbResp, re := report.Members.readableReport(w, r, rID)
+	if dbResp == nil {
+		return
+	}
+
+	// reads of merged reports go to the report they were merged into unless
+	// the caller asks for the duplicate itself with redirect=false
+	if dbResp.DuplicateOf != "" && r.URL.Query().Get("redirect") != "false" {
+		http.Redirect(w, r, "/api/report/"+dbResp.DuplicateOf, http.StatusMovedPermanently)
+		return
+	}
+
+	linked, err := report.linkedReports(r.Context(), re, dbResp)
+	if err != nil {
+		config.ErrorStatus("failed to get linked reports", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// readers other than the reporter, assignees and admins see the disclosed report
+	if !re.privileged(dbResp) {
+		redact(dbResp)
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp, "links": linked, "sla": slaStatus(dbResp.SLA, time.Now().UTC())},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// SearchReportsHandler returns a page of reports whose title or description
+// match the words in the q query parameter, optionally within one project
+func (report Report) SearchReportsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		config.ErrorStatus("missing search query", http.StatusBadRequest, w, nil)
+		return
+	}
+
+	filter, err := reportFilter(r.URL.Query())
+	if err != nil {
+		config.ErrorStatus("invalid report filter", http.StatusBadRequest, w, err)
+		return
+	}
+	filter["$text"] = bson.M{"$search": query}
+	if projectID := r.URL.Query().Get("projectId"); projectID != "" {
+		filter["projectId"] = projectID
+	}
+
+	sort, err := reportSort(r.URL.Query(), databases.FindOptions{})
+	if err != nil {
+		config.ErrorStatus("invalid report sort", http.StatusBadRequest, w, err)
+		return
+	}
+
+	// only list the reports the caller can read
+	re, err := report.Members.reader(ctx)
+	if err != nil {
+		config.ErrorStatus("failed to check report visibility", http.StatusInternalServerError, w, err)
+		return
+	}
+	filter["$and"] = bson.A{re.filter()}
+
+	dbResp, err := report.DB.FindPage(ctx, filter, pageFromRequest(r), sort)
 	if err != nil {
-		config.ErrorStatus("failed to get report by ID", http.StatusNotFound, w, err)
+		config.ErrorStatus("failed to search reports", http.StatusInternalServerError, w, err)
 		return
 	}
+	re.present(dbResp.Items)
 
 	b, err := json.Marshal(
 		models.DataResponse{
@@ -55,11 +143,143 @@ func (report Report) ReportByObjectIDHandler(w http.ResponseWriter, r *http.Requ
 	w.Write(b)
 }
 
+// ProjectReportsHandler returns a page of a project's reports, newest first
+// unless reportSort says otherwise, narrowed by the filters reportFilter reads
+// from the query
+func (report Report) ProjectReportsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, err := reportFilter(r.URL.Query())
+	if err != nil {
+		config.ErrorStatus("invalid report filter", http.StatusBadRequest, w, err)
+		return
+	}
+	filter["projectId"] = mux.Vars(r)["project_id"]
+
+	sort, err := reportSort(r.URL.Query(), reportSorts["newest"])
+	if err != nil {
+		config.ErrorStatus("invalid report sort", http.StatusBadRequest, w, err)
+		return
+	}
+
+	// only list the reports the caller can read
+	re, err := report.Members.reader(ctx)
+	if err != nil {
+		config.ErrorStatus("failed to check report visibility", http.StatusInternalServerError, w, err)
+		return
+	}
+	filter["$and"] = bson.A{re.filter()}
+
+	dbResp, err := report.DB.FindPage(ctx, filter, pageFromRequest(r), sort)
+	if err != nil {
+		config.ErrorStatus("failed to get project reports", http.StatusInternalServerError, w, err)
+		return
+	}
+	re.present(dbResp.Items)
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// reportSorts are the orders report listings can be requested in with the sort parameter
+var reportSorts = map[string]databases.FindOptions{
+	"newest": {Sort: bson.D{{Key: "_id", Value: -1}}},
+	"oldest": {Sort: bson.D{{Key: "_id", Value: 1}}},
+	"votes":  {Sort: bson.D{{Key: "votes", Value: -1}, {Key: "_id", Value: -1}}},
+}
+
+// reportSort returns the order a report listing asked for in the sort query
+// parameter, or fallback when it didn't ask
+func reportSort(query url.Values, fallback databases.FindOptions) (databases.FindOptions, error) {
+	name := query.Get("sort")
+	if name == "" {
+		return fallback, nil
+	}
+
+	sort, ok := reportSorts[name]
+	if !ok {
+		return databases.FindOptions{}, fmt.Errorf("unknown sort %q, use newest, oldest or votes", name)
+	}
+	return sort, nil
+}
+
+// reportFilter builds a report filter from the query parameters shared by the
+// report listings. Every label parameter must match, the others match exactly
+// and multi-select fields match when they contain the value
+func reportFilter(query url.Values) (bson.M, error) {
+	filter := bson.M{}
+
+	if labels := query["label"]; len(labels) > 0 {
+		filter["labels"] = bson.M{"$all": labels}
+	}
+	if component := query.Get("component"); component != "" {
+		filter["components"] = component
+	}
+	if state := query.Get("state"); state != "" {
+		filter["state"] = state
+	}
+	if assignee := query.Get("assigneeId"); assignee != "" {
+		filter["assigneeIds"] = assignee
+	}
+	if version := query.Get("affectedVersion"); version != "" {
+		filter["affectedVersions"] = version
+	}
+	if version := query.Get("fixVersion"); version != "" {
+		filter["fixVersion"] = version
+	}
+	if resolved := query.Get("resolved"); resolved != "" {
+		value, err := strconv.ParseBool(resolved)
+		if err != nil {
+			return nil, fmt.Errorf("resolved must be true or false: %w", err)
+		}
+		filter["resolved"] = value
+	}
+	if severity := query.Get("severity"); severity != "" {
+		value, ok := models.SeverityLevels[severity]
+		if !ok {
+			return nil, fmt.Errorf("unknown severity level %q", severity)
+		}
+		filter["severity"] = value
+	}
+
+	// custom fields are filtered with field.<key>=value, numbers match either
+	// the number or the text since the filter doesn't know the field's type
+	for param, values := range query {
+		key, ok := strings.CutPrefix(param, "field.")
+		if !ok {
+			continue
+		}
+		if err := validate.Var(key, "required,alphanum"); err != nil {
+			return nil, fmt.Errorf("invalid custom field %q", key)
+		}
+
+		value := values[0]
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			filter["fields."+key] = bson.M{"$in": bson.A{value, number}}
+		} else {
+			filter["fields."+key] = value
+		}
+	}
+	return filter, nil
+}
+
 // Create a new report
 func (report Report) NewReportHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx := r.Context()
 	var details models.ReportDetails // Json data will represent the report details model
-	defer cancel()
 
 	// validate the request body
 	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
@@ -75,22 +295,169 @@ func (report Report) NewReportHandler(w http.ResponseWriter, r *http.Request) {
 
 	// TODO: add validation to title / description length
 
+	pID, err := primitive.ObjectIDFromHex(details.ProjectID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	project, err := report.Members.Projects.FindByID(ctx, pID)
+	if err != nil {
+		config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	if archived(w, project) {
+		return
+	}
+
+	// private and invite only projects only take reports from their members
+	re, err := report.Members.reader(ctx)
+	if err != nil {
+		config.ErrorStatus("failed to check project visibility", http.StatusInternalServerError, w, err)
+		return
+	}
+	if project.Visibility == models.ProjectPrivate || project.Visibility == models.ProjectInviteOnly {
+		roles, err := report.Members.roles(ctx, project, re.userID)
+		if err != nil {
+			config.ErrorStatus("failed to get project roles", http.StatusInternalServerError, w, err)
+			return
+		}
+		if len(roles) == 0 && project.Visibility == models.ProjectPrivate {
+			config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, nil)
+			return
+		}
+		if len(roles) == 0 {
+			config.ErrorStatus("only project members can report to an invite only project", http.StatusForbidden, w, nil)
+			return
+		}
+	}
+
+	if name := undefinedName(details.Labels, labelNames(project)); name != "" {
+		config.ErrorStatus(fmt.Sprintf("%q is not one of the project's labels", name), http.StatusBadRequest, w, nil)
+		return
+	}
+	if name := undefinedName(details.Components, componentNames(project)); name != "" {
+		config.ErrorStatus(fmt.Sprintf("%q is not one of the project's components", name), http.StatusBadRequest, w, nil)
+		return
+	}
+	if err := validateSections(templateSections(project.Template), details.Sections); err != nil {
+		config.ErrorStatus("report does not match the project template", http.StatusBadRequest, w, err)
+		return
+	}
+	templateVersion, err := report.Templates.version(ctx, project, "")
+	if err != nil {
+		config.ErrorStatus("failed to record template version", http.StatusInternalServerError, w, err)
+		return
+	}
+	if details.Sections == nil {
+		details.Sections = map[string]string{}
+	}
+	fields, err := validateFields(project.CustomFields, details.Fields)
+	if err != nil {
+		config.ErrorStatus("invalid custom fields", http.StatusBadRequest, w, err)
+		return
+	}
+	if err := validateVersions(project, details.AffectedVersions, details.FixVersion); err != nil {
+		config.ErrorStatus("invalid versions", http.StatusBadRequest, w, err)
+		return
+	}
+	if details.Labels == nil {
+		details.Labels = []string{}
+	}
+	if details.Components == nil {
+		details.Components = []string{}
+	}
+	if details.AffectedVersions == nil {
+		details.AffectedVersions = []string{}
+	}
+
+	// point reporters at open reports that look the same before accepting a new one
+	if !details.IgnoreDuplicates {
+		candidates, err := report.duplicates(ctx, re, details.ProjectID, details.Title, details.Des)
+		if err != nil {
+			config.ErrorStatus("failed to look for duplicate reports", http.StatusInternalServerError, w, err)
+			return
+		}
+
+		if len(candidates) > 0 {
+			b, err := json.Marshal(
+				models.DataResponse{
+					Status:  http.StatusConflict,
+					Message: "possible duplicates found, resubmit with ignoreDuplicates to create the report anyway",
+					Data:    map[string]any{"duplicates": candidates},
+				},
+			)
+
+			if err != nil {
+				config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+				return
+			}
+
+			w.WriteHeader(http.StatusConflict)
+			w.Write(b)
+			return
+		}
+	}
+
+	assignees, err := report.autoAssign(ctx, project, details.Labels)
+	if err != nil {
+		config.ErrorStatus("failed to assign report", http.StatusInternalServerError, w, err)
+		return
+	}
+
 	newReport := models.Report{
 		ID:        primitive.NewObjectID(),
 		AuthorID:  details.AuthorID,
 		ProjectID: details.ProjectID,
 		Title:     details.Title,
 		Des:       details.Des,
-		Severity:  -1, // -1 indicates it hasn't been assigned a severity level
+		Severity:  models.SeverityUntriaged,
 		Resolved:  false,
+		State:     projectWorkflow(project).InitialState,
+		History:   []models.StateChange{},
+
+		Visibility: models.ReportPrivate,
+
+		AssigneeIDs: assignees,
+		Labels:      details.Labels,
+		Components:  details.Components,
+
+		Sections: details.Sections,
+		Fields:   fields,
+
+		TemplateVersion: templateVersion,
+
+		Links: []models.ReportLink{},
+
+		AffectedVersions: details.AffectedVersions,
+		FixVersion:       details.FixVersion,
+	}
+	if project.SLA != nil {
+		newReport.SLA = slaDeadlines(project.SLA, &newReport)
 	}
 
-	result, err := report.DB.InsertOne(ctx, newReport)
+	result, err := report.DB.InsertOne(ctx, &newReport)
 	if err != nil {
 		config.ErrorStatus("failed to insert project", http.StatusBadRequest, w, err)
 		return
 	}
 
+	if err := report.takeTurn(ctx, project, &newReport); err != nil {
+		config.ErrorStatus("failed to assign report", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	reportID := newReport.ID.Hex()
+	if err := report.Watchers.watch(ctx, []string{newReport.AuthorID}, models.WatchReport, reportID, newReport.ProjectID, models.WatchAuthor); err != nil {
+		config.ErrorStatus("failed to watch report", http.StatusInternalServerError, w, err)
+		return
+	}
+	if err := report.Watchers.watch(ctx, newReport.AssigneeIDs, models.WatchReport, reportID, newReport.ProjectID, models.WatchAssignee); err != nil {
+		config.ErrorStatus("failed to watch report", http.StatusInternalServerError, w, err)
+		return
+	}
+
 	b, err := json.Marshal(
 		models.DataResponse{
 			Status:  http.StatusCreated,
@@ -108,11 +475,11 @@ func (report Report) NewReportHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(b)
 }
 
-// UpdateReportHandler updates the attributes of a report
+// UpdateReportHandler updates the attributes of a report, only its reporter and
+// project admins can update it
 func (report Report) UpdateReportHanlder(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx := r.Context()
 	var newDetails models.ReportUpdateDetails
-	defer cancel()
 
 	reportID := mux.Vars(r)["report_id"]
 
@@ -135,11 +502,148 @@ func (report Report) UpdateReportHanlder(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	current := report.Members.editableReport(w, r, rID)
+	if current == nil || report.Members.readOnly(w, r, current.ProjectID) {
+		return
+	}
+
 	update := util.BuildUpdate(newDetails)
 
-	dbResp, err := report.DB.UpdateOne(
+	// changed sections and fields are merged into the current ones and the
+	// result has to match the project the report ends up in, as do versions
+	moved := newDetails.ProjectID != ""
+	versions := newDetails.AffectedVersions != nil || newDetails.FixVersion != nil
+
+	// reports can only move into projects the user can see that still take reports
+	if moved && (report.Members.visibleProject(w, r, newDetails.ProjectID) == nil || report.Members.readOnly(w, r, newDetails.ProjectID)) {
+		return
+	}
+
+	if newDetails.Sections != nil || newDetails.Fields != nil || versions || moved {
+		projectID := current.ProjectID
+		if moved {
+			projectID = newDetails.ProjectID
+		}
+
+		pID, err := primitive.ObjectIDFromHex(projectID)
+		if err != nil {
+			config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+			return
+		}
+
+		project, err := report.Members.Projects.FindByID(ctx, pID)
+		if err != nil {
+			config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, err)
+			return
+		}
+
+		if moved {
+			// reports from before workflows are in the initial state of whichever project they are in
+			if _, ok := workflowState(projectWorkflow(project), current.State); current.State != "" && !ok {
+				err := fmt.Errorf("the project workflow has no %q state", current.State)
+				config.ErrorStatus("the report is in a state the project does not define, move it out first", http.StatusConflict, w, err)
+				return
+			}
+
+			// labels, components and assignees the project doesn't have are left behind
+			labels, components := []string{}, []string{}
+			for _, label := range current.Labels {
+				if slices.Contains(labelNames(project), label) {
+					labels = append(labels, label)
+				}
+			}
+			for _, component := range current.Components {
+				if slices.Contains(componentNames(project), component) {
+					components = append(components, component)
+				}
+			}
+			update["labels"] = labels
+			update["components"] = components
+
+			assignees, err := report.Members.memberIDs(ctx, projectID, current.AssigneeIDs)
+			if err != nil {
+				config.ErrorStatus("failed to get project members", http.StatusInternalServerError, w, err)
+				return
+			}
+			update["assigneeIds"] = assignees
+		}
+
+		if newDetails.Sections != nil || moved {
+			// sections the template has since dropped are left behind
+			template := templateSections(project.Template)
+			sections := map[string]string{}
+			for _, section := range template {
+				if value, ok := current.Sections[section.Key]; ok {
+					sections[section.Key] = value
+				}
+			}
+			maps.Copy(sections, newDetails.Sections)
+
+			if err := validateSections(template, sections); err != nil {
+				config.ErrorStatus("report does not match the project template", http.StatusBadRequest, w, err)
+				return
+			}
+			update["sections"] = sections
+
+			version, err := report.Templates.version(ctx, project, "")
+			if err != nil {
+				config.ErrorStatus("failed to record template version", http.StatusInternalServerError, w, err)
+				return
+			}
+			update["templateVersion"] = version
+		}
+
+		if newDetails.Fields != nil || moved {
+			// as are values of fields the project has since dropped
+			values := map[string]any{}
+			for _, field := range project.CustomFields {
+				if value, ok := current.Fields[field.Key]; ok {
+					values[field.Key] = value
+				}
+			}
+			maps.Copy(values, newDetails.Fields)
+
+			fields, err := validateFields(project.CustomFields, values)
+			if err != nil {
+				config.ErrorStatus("invalid custom fields", http.StatusBadRequest, w, err)
+				return
+			}
+			update["fields"] = fields
+		}
+
+		if versions || moved {
+			// versions of the project a report moves out of don't carry over
+			affected, fix := []string{}, ""
+			if !moved {
+				affected, fix = current.AffectedVersions, current.FixVersion
+			}
+			if newDetails.AffectedVersions != nil {
+				affected = newDetails.AffectedVersions
+			}
+			if newDetails.FixVersion != nil {
+				fix = *newDetails.FixVersion
+			}
+
+			if err := validateVersions(project, affected, fix); err != nil {
+				config.ErrorStatus("invalid versions", http.StatusBadRequest, w, err)
+				return
+			}
+			if affected == nil {
+				affected = []string{}
+			}
+			update["affectedVersions"] = affected
+			update["fixVersion"] = fix
+		}
+
+		// open reports are held to the SLA of the project they move into
+		if moved && project.SLA != nil && !current.Resolved {
+			update["sla"] = slaDeadlines(project.SLA, current)
+		}
+	}
+
+	dbResp, err := report.DB.UpdateByID(
 		ctx,
-		bson.M{"_id": rID},
+		rID,
 		bson.M{"$set": update},
 	)
 
@@ -148,6 +652,19 @@ func (report Report) UpdateReportHanlder(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("report not found", http.StatusNotFound, w, nil)
+		return
+	}
+
+	// points the report earned count towards the project it moved into
+	if moved {
+		if err := report.Reputation.sync(ctx, reportID); err != nil {
+			config.ErrorStatus("failed to update reputation", http.StatusInternalServerError, w, err)
+			return
+		}
+	}
+
 	b, err := json.Marshal(
 		models.DataResponse{
 			Status:  http.StatusOK,
@@ -166,8 +683,7 @@ func (report Report) UpdateReportHanlder(w http.ResponseWriter, r *http.Request)
 }
 
 func (report Report) DeleteReportByIdHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	reportID := mux.Vars(r)["report_id"]
 
@@ -178,17 +694,46 @@ func (report Report) DeleteReportByIdHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	dbResp, err := report.DB.DeleteOne(ctx, bson.M{"_id": uID})
+	if report.Members.editableReport(w, r, uID) == nil {
+		return
+	}
+
+	dbResp, err := report.DB.DeleteByID(ctx, uID)
 	if err != nil {
 		config.ErrorStatus("failed to delete report", http.StatusNotFound, w, err)
 		return
 	}
 
-	if dbResp.Dr.DeletedCount == 0 {
+	if dbResp.DeletedCount == 0 {
 		config.ErrorStatus("report not found", http.StatusNotFound, w, nil)
 		return
 	}
 
+	if err := report.Attachments.remove(ctx, bson.M{"reportId": reportID}); err != nil {
+		config.ErrorStatus("failed to remove report attachments", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if err := report.unlink(ctx, bson.M{}, reportID); err != nil {
+		config.ErrorStatus("failed to remove links to the report", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if _, err := report.Watchers.DB.DeleteMany(ctx, bson.M{"targetType": models.WatchReport, "targetId": reportID}); err != nil {
+		config.ErrorStatus("failed to remove report watchers", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if _, err := report.Votes.DB.DeleteMany(ctx, bson.M{"reportId": reportID}); err != nil {
+		config.ErrorStatus("failed to remove report votes", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if err := report.Reputation.sync(ctx, reportID); err != nil {
+		config.ErrorStatus("failed to update reputation", http.StatusInternalServerError, w, err)
+		return
+	}
+
 	b, err := json.Marshal(
 		models.DataResponse{
 			Status:  http.StatusOK,
@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// reputationOutcomes maps the terminal workflow states reputation knows about
+// onto the events they earn, reports resolved in other states earn nothing
+var reputationOutcomes = map[string]string{
+	"resolved":  models.ReputationValid,
+	"fixed":     models.ReputationValid,
+	"duplicate": models.ReputationDuplicate,
+	"wont_fix":  models.ReputationInvalid,
+	"invalid":   models.ReputationInvalid,
+}
+
+// leaderboardWindows are how far back leaderboards count points, 0 counts everything
+var leaderboardWindows = map[string]time.Duration{
+	"30d": 30 * 24 * time.Hour,
+	"all": 0,
+}
+
+// Reputation scores researchers on what their reports earn. The reputation
+// collection is an event log of those earnings and User.Reputation is its sum,
+// both can be rebuilt from the reports and bounties at any time
+type Reputation struct {
+	DB       databases.ReputationDatabase
+	Reports  databases.ReportDatabase
+	Users    databases.UserDatabase
+	Bounties databases.BountyDatabase
+	Members  Membership
+}
+
+// earned returns the events a report has earned its author as it stands now
+func (reputation Reputation) earned(ctx context.Context, report *models.Report) ([]models.ReputationEvent, error) {
+	events := []models.ReputationEvent{}
+	if report.AuthorID == "" {
+		return events, nil
+	}
+
+	earn := func(kind string, points int) {
+		events = append(events, models.ReputationEvent{
+			UserID:    report.AuthorID,
+			ProjectID: report.ProjectID,
+			ReportID:  report.ID.Hex(),
+			Kind:      kind,
+			Points:    points,
+		})
+	}
+
+	if report.Resolved {
+		outcome := reputationOutcomes[report.State]
+		if report.DuplicateOf != "" {
+			outcome = models.ReputationDuplicate
+		}
+		if outcome != "" {
+			earn(outcome, models.ReputationPoints[outcome])
+		}
+		if points := models.SeverityPoints[report.Severity]; outcome == models.ReputationValid && points > 0 {
+			earn(models.ReputationSeverity, points)
+		}
+	}
+
+	awarded, err := reputation.Bounties.Exists(ctx, bson.M{"reportId": report.ID.Hex(), "userId": report.AuthorID})
+	if err != nil {
+		return nil, err
+	}
+	if awarded {
+		earn(models.ReputationBounty, models.ReputationPoints[models.ReputationBounty])
+	}
+	return events, nil
+}
+
+// sync brings the event log for a report in line with what it has earned and
+// rescores the users affected. Events already logged keep when they were
+// earned, so running it again changes nothing. Deleted reports lose their events
+func (reputation Reputation) sync(ctx context.Context, reportID string) error {
+	rID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		return err
+	}
+
+	earned := []models.ReputationEvent{}
+	report, err := reputation.Reports.FindByID(ctx, rID)
+	if err != nil && !errors.Is(err, databases.ErrNotFound) {
+		return err
+	}
+	if report != nil {
+		if earned, err = reputation.earned(ctx, report); err != nil {
+			return err
+		}
+	}
+
+	logged, err := reputation.DB.Find(ctx, bson.M{"reportId": reportID})
+	if err != nil {
+		return err
+	}
+
+	users, stale := []string{}, bson.A{}
+	for _, event := range logged {
+		same := func(e models.ReputationEvent) bool {
+			return e.Kind == event.Kind && e.Points == event.Points && e.UserID == event.UserID && e.ProjectID == event.ProjectID
+		}
+		if !slices.ContainsFunc(earned, same) {
+			stale = append(stale, event.ID)
+		}
+		users = append(users, event.UserID)
+	}
+
+	if len(stale) > 0 {
+		if _, err := reputation.DB.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": stale}}); err != nil {
+			return err
+		}
+	}
+
+	for _, event := range earned {
+		event.ID, event.CreatedAt = primitive.NewObjectID(), time.Now().UTC()
+		if _, err := reputation.DB.InsertOne(ctx, &event); err != nil && !errors.Is(err, databases.ErrDuplicateKey) {
+			return err
+		}
+		users = append(users, event.UserID)
+	}
+
+	slices.Sort(users)
+	for _, userID := range slices.Compact(users) {
+		if err := reputation.rescore(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rescore sets a user's reputation to the sum of their logged events
+func (reputation Reputation) rescore(ctx context.Context, userID string) error {
+	uID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+
+	events, err := reputation.DB.Find(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return err
+	}
+
+	score := 0
+	for _, event := range events {
+		score += event.Points
+	}
+
+	_, err = reputation.Users.UpdateByID(ctx, uID, bson.M{"$set": bson.M{"reputation": score}})
+	return err
+}
+
+// RecalculateReputationHandler rebuilds a user's reputation from every report
+// they wrote. Users can only recalculate their own
+func (reputation Reputation) RecalculateReputationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := mux.Vars(r)["user_id"]
+
+	uID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	if caller, _ := api.UserIDFromContext(ctx); caller != userID {
+		config.ErrorStatus("you can only recalculate your own reputation", http.StatusForbidden, w, nil)
+		return
+	}
+
+	reports, err := reputation.Reports.Find(ctx, bson.M{"author": userID}, databases.FindOptions{Projection: bson.M{"_id": 1}})
+	if err != nil {
+		config.ErrorStatus("failed to get user reports", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// reports that were deleted only show up in the log, syncing them drops their events
+	logged, err := reputation.DB.Find(ctx, bson.M{"userId": userID})
+	if err != nil {
+		config.ErrorStatus("failed to get reputation events", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	reportIDs := []string{}
+	for _, report := range reports {
+		reportIDs = append(reportIDs, report.ID.Hex())
+	}
+	for _, event := range logged {
+		reportIDs = append(reportIDs, event.ReportID)
+	}
+	slices.Sort(reportIDs)
+
+	for _, reportID := range slices.Compact(reportIDs) {
+		if err := reputation.sync(ctx, reportID); err != nil {
+			config.ErrorStatus("failed to recalculate reputation", http.StatusInternalServerError, w, err)
+			return
+		}
+	}
+
+	// users without any events are still rescored back to zero
+	if err := reputation.rescore(ctx, userID); err != nil {
+		config.ErrorStatus("failed to recalculate reputation", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	dbResp, err := reputation.Users.FindByID(ctx, uID)
+	if err != nil {
+		config.ErrorStatus("failed to get user by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp.Reputation},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// UserReputationHandler returns a page of the events that make up a user's reputation, newest first
+func (reputation Reputation) UserReputationHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := mux.Vars(r)["user_id"]
+
+	newestFirst := databases.FindOptions{Sort: bson.D{{Key: "createdAt", Value: -1}}}
+	dbResp, err := reputation.DB.FindPage(ctx, bson.M{"userId": userID}, pageFromRequest(r), newestFirst)
+	if err != nil {
+		config.ErrorStatus("failed to get reputation events", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// LeaderboardHandler ranks users by the points they earned across every project
+func (reputation Reputation) LeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	reputation.leaderboard(w, r, bson.M{})
+}
+
+// ProjectLeaderboardHandler ranks users by the points they earned in a project
+func (reputation Reputation) ProjectLeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["project_id"]
+	if reputation.Members.visibleProject(w, r, projectID) == nil {
+		return
+	}
+	reputation.leaderboard(w, r, bson.M{"projectId": projectID})
+}
+
+// leaderboard returns a page of users ranked by the points of the events
+// matching filter. The window query parameter limits it to events from the
+// last 30 days with 30d, or counts everything with all, the default.
+// Users with the same points share a rank
+func (reputation Reputation) leaderboard(w http.ResponseWriter, r *http.Request, filter bson.M) {
+	ctx := r.Context()
+
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "all"
+	}
+	since, ok := leaderboardWindows[window]
+	if !ok {
+		config.ErrorStatus("window must be 30d or all", http.StatusBadRequest, w, nil)
+		return
+	}
+	if since > 0 {
+		filter["createdAt"] = bson.M{"$gte": time.Now().UTC().Add(-since)}
+	}
+
+	events, err := reputation.DB.Find(ctx, filter)
+	if err != nil {
+		config.ErrorStatus("failed to get reputation events", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	points := map[string]int{}
+	for _, event := range events {
+		points[event.UserID] += event.Points
+	}
+
+	userIDs := bson.A{}
+	for userID := range points {
+		if uID, err := primitive.ObjectIDFromHex(userID); err == nil {
+			userIDs = append(userIDs, uID)
+		}
+	}
+
+	users, err := reputation.Users.Find(ctx, bson.M{"_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		config.ErrorStatus("failed to get users", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	entries := []models.LeaderboardEntry{}
+	for _, u := range users {
+		entries = append(entries, models.LeaderboardEntry{User: u, Points: points[u.ID.Hex()]})
+	}
+
+	slices.SortFunc(entries, func(a, b models.LeaderboardEntry) int {
+		if a.Points != b.Points {
+			return b.Points - a.Points
+		}
+		return strings.Compare(a.User.Username, b.User.Username)
+	})
+	for i := range entries {
+		entries[i].Rank = i + 1
+		if i > 0 && entries[i].Points == entries[i-1].Points {
+			entries[i].Rank = entries[i-1].Rank
+		}
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": databases.Paginate(entries, pageFromRequest(r)), "window": window},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
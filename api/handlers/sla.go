@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// slaFields are the ReportSLA fields holding when each commitment is due and when it was met
+var slaFields = map[string][2]string{
+	models.SLAFirstResponse: {"firstResponseBy", "firstResponseAt"},
+	models.SLATriage:        {"triageBy", "triagedAt"},
+	models.SLAResolve:       {"resolveBy", "resolvedAt"},
+}
+
+// severityName returns the name SLA targets use for a report severity
+func severityName(severity int) string {
+	for name, level := range models.SeverityLevels {
+		if level == severity {
+			return name
+		}
+	}
+	return "untriaged"
+}
+
+// slaDeadlines works out a report's deadlines under a policy from when it was
+// submitted and its current severity, keeping when commitments were met.
+// A report triaged before it had deadlines counts as triaged when it was
+func slaDeadlines(policy *models.SLAPolicy, report *models.Report) *models.ReportSLA {
+	sla := &models.ReportSLA{Breaches: []string{}}
+	if report.SLA != nil {
+		*sla = *report.SLA
+		if sla.Breaches == nil {
+			sla.Breaches = []string{}
+		}
+	}
+	sla.FirstResponseBy, sla.TriageBy, sla.ResolveBy = nil, nil, nil
+
+	if sla.TriagedAt == nil && report.Triage != nil {
+		triagedAt := report.Triage.TriagedAt
+		sla.TriagedAt = &triagedAt
+	}
+
+	submitted := report.ID.Timestamp().UTC()
+	deadline := func(hours int) *time.Time {
+		if hours == 0 {
+			return nil
+		}
+		due := submitted.Add(time.Duration(hours) * time.Hour)
+		return &due
+	}
+
+	severity := severityName(report.Severity)
+	for _, target := range policy.Targets {
+		if target.Severity == severity {
+			sla.FirstResponseBy = deadline(target.FirstResponseHours)
+			sla.TriageBy = deadline(target.TriageHours)
+			sla.ResolveBy = deadline(target.ResolveHours)
+		}
+	}
+	return sla
+}
+
+// slaTimes returns when a commitment of a report is due and when it was met
+func slaTimes(sla *models.ReportSLA, kind string) (*time.Time, *time.Time) {
+	switch kind {
+	case models.SLAFirstResponse:
+		return sla.FirstResponseBy, sla.FirstResponseAt
+	case models.SLATriage:
+		return sla.TriageBy, sla.TriagedAt
+	default:
+		return sla.ResolveBy, sla.ResolvedAt
+	}
+}
+
+// slaStatus returns where each commitment with a deadline stands at now
+func slaStatus(sla *models.ReportSLA, now time.Time) []models.SLAStatus {
+	statuses := []models.SLAStatus{}
+	if sla == nil {
+		return statuses
+	}
+
+	for _, kind := range models.SLAKinds {
+		due, met := slaTimes(sla, kind)
+		if due == nil {
+			continue
+		}
+
+		status := models.SLAStatus{Kind: kind, State: models.SLAPending, Due: due, MetAt: met}
+		switch {
+		case met != nil && met.After(*due):
+			status.State = models.SLALate
+		case met != nil:
+			status.State = models.SLAMet
+		case now.After(*due):
+			status.State = models.SLABreached
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// firstResponse meets a report's first response deadline when a project member
+// other than its author comments on it for the first time
+func (comment Comment) firstResponse(ctx context.Context, report *models.Report, userID string) error {
+	if report.SLA == nil || report.SLA.FirstResponseAt != nil || report.AuthorID == userID {
+		return nil
+	}
+
+	pID, err := primitive.ObjectIDFromHex(report.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	members := comment.Attachments.Members
+	project, err := members.Projects.FindByID(ctx, pID)
+	if err != nil {
+		return err
+	}
+
+	roles, err := members.roles(ctx, project, userID)
+	if err != nil || len(roles) == 0 {
+		return err
+	}
+
+	filter := bson.M{"_id": report.ID, "sla.firstResponseAt": bson.M{"$exists": false}}
+	_, err = comment.Watchers.Reports.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"sla.firstResponseAt": time.Now().UTC()}})
+	return err
+}
+
+// firstResponseAt returns when someone other than the author who is a member
+// of the project first commented on a report, comments must be oldest first
+func firstResponseAt(report *models.Report, comments []models.Comment, isMember func(userID string) bool) *time.Time {
+	for _, comment := range comments {
+		if comment.AuthorID != report.AuthorID && isMember(comment.AuthorID) {
+			respondedAt := comment.ID.Timestamp().UTC()
+			return &respondedAt
+		}
+	}
+	return nil
+}
+
+// respondedAt looks through the comments on a report for its first response,
+// members' roles are remembered across calls so each user is looked up once
+func (project Project) respondedAt(ctx context.Context, current *models.Project, report *models.Report, members map[string]bool) (*time.Time, error) {
+	oldestFirst := databases.FindOptions{Sort: bson.D{{Key: "_id", Value: 1}}}
+	comments, err := project.Comments.Find(ctx, bson.M{"reportId": report.ID.Hex()}, oldestFirst)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, comment := range comments {
+		if _, known := members[comment.AuthorID]; known {
+			continue
+		}
+		roles, err := project.Members.roles(ctx, current, comment.AuthorID)
+		if err != nil {
+			return nil, err
+		}
+		members[comment.AuthorID] = len(roles) > 0
+	}
+	return firstResponseAt(report, comments, func(userID string) bool { return members[userID] }), nil
+}
+
+// UpdateSLAHandler replaces the SLA policy of a project, only project admins can change it.
+// Deadlines of the project's unresolved reports are worked out again under the new policy
+func (project Project) UpdateSLAHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var policy models.SLAPolicy
+
+	projectID := mux.Vars(r)["project_id"]
+
+	current := projectAdmin(ctx, w, r, project.DB, projectID)
+	if current == nil || archived(w, current) {
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&policy); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	dbResp, err := project.DB.UpdateByID(ctx, current.ID, bson.M{"$set": bson.M{"sla": policy}})
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	reports, err := project.Members.Reports.Find(ctx, bson.M{"projectId": projectID, "resolved": false})
+	if err != nil {
+		config.ErrorStatus("failed to get project reports", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	members := map[string]bool{}
+	for _, report := range reports {
+		sla := slaDeadlines(&policy, &report)
+
+		// reports commented on before they had deadlines already had their first response
+		if sla.FirstResponseAt == nil {
+			if sla.FirstResponseAt, err = project.respondedAt(ctx, current, &report, members); err != nil {
+				config.ErrorStatus("failed to get report comments", http.StatusInternalServerError, w, err)
+				return
+			}
+		}
+
+		update := bson.M{"$set": bson.M{"sla": sla}}
+		if _, err := project.Members.Reports.UpdateByID(ctx, report.ID, update); err != nil {
+			config.ErrorStatus("failed to update report deadlines", http.StatusInternalServerError, w, err)
+			return
+		}
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// DeleteSLAHandler removes the SLA policy of a project along with the
+// deadlines of its unresolved reports, only project admins can remove it
+func (project Project) DeleteSLAHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	projectID := mux.Vars(r)["project_id"]
+
+	current := projectAdmin(ctx, w, r, project.DB, projectID)
+	if current == nil || archived(w, current) {
+		return
+	}
+
+	dbResp, err := project.DB.UpdateByID(ctx, current.ID, bson.M{"$unset": bson.M{"sla": ""}})
+	if err != nil {
+		config.ErrorStatus("the project could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	filter := bson.M{"projectId": projectID, "resolved": false}
+	if _, err := project.Members.Reports.UpdateMany(ctx, filter, bson.M{"$unset": bson.M{"sla": ""}}); err != nil {
+		config.ErrorStatus("failed to remove report deadlines", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// SLAMonitor periodically flags reports whose SLA deadlines have passed and
+// tells their watchers, each commitment of a report is flagged once
+type SLAMonitor struct {
+	Reports databases.ReportDatabase
+	Events  Event
+}
+
+// check flags every unresolved report with a commitment that was due before
+// now and not met, returning how many breaches it flagged
+func (monitor SLAMonitor) check(ctx context.Context, now time.Time) (int, error) {
+	flagged := 0
+	for _, kind := range models.SLAKinds {
+		due, met := "sla."+slaFields[kind][0], "sla."+slaFields[kind][1]
+
+		filter := bson.M{
+			"resolved":     false,
+			due:            bson.M{"$lte": now},
+			met:            bson.M{"$exists": false},
+			"sla.breaches": bson.M{"$ne": kind},
+		}
+
+		reports, err := monitor.Reports.Find(ctx, filter)
+		if err != nil {
+			return flagged, err
+		}
+
+		for _, report := range reports {
+			// another instance may have flagged the breach since we looked
+			update := bson.M{"$addToSet": bson.M{"sla.breaches": kind}}
+			dbResp, err := monitor.Reports.UpdateOne(ctx, bson.M{"_id": report.ID, "sla.breaches": bson.M{"$ne": kind}}, update)
+			if err != nil {
+				return flagged, err
+			}
+			if dbResp.ModifiedCount == 0 {
+				continue
+			}
+			flagged++
+
+			deadline, _ := slaTimes(report.SLA, kind)
+			err = monitor.Events.emit(ctx, models.Event{
+				Type:      models.EventSLABreached,
+				ProjectID: report.ProjectID,
+				ReportID:  report.ID.Hex(),
+				Data:      map[string]any{"kind": kind, "due": deadline},
+			})
+			if err != nil {
+				return flagged, err
+			}
+		}
+	}
+	return flagged, nil
+}
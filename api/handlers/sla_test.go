@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+func TestSLADeadlinesKeepsMetTimes(t *testing.T) {
+	submitted := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	triaged := submitted.Add(3 * time.Hour)
+	earlier := submitted.Add(time.Hour)
+	policy := &models.SLAPolicy{Targets: []models.SLATarget{{Severity: "untriaged", FirstResponseHours: 4, TriageHours: 24}}}
+
+	cases := []struct {
+		name      string
+		report    models.Report
+		triagedAt *time.Time
+	}{
+		{
+			name:   "never triaged",
+			report: models.Report{},
+		},
+		{
+			// triaged while the project had no policy
+			name:      "triaged without deadlines",
+			report:    models.Report{Triage: &models.Triage{TriagedAt: triaged}},
+			triagedAt: &triaged,
+		},
+		{
+			name:      "triage already met",
+			report:    models.Report{Triage: &models.Triage{TriagedAt: triaged}, SLA: &models.ReportSLA{TriagedAt: &earlier}},
+			triagedAt: &earlier,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.report.ID = primitive.NewObjectIDFromTimestamp(submitted)
+			tc.report.Severity = models.SeverityUntriaged
+			sla := slaDeadlines(policy, &tc.report)
+
+			if sla.TriageBy == nil || !sla.TriageBy.Equal(submitted.Add(24*time.Hour)) {
+				t.Errorf("triage due %v, want %v", sla.TriageBy, submitted.Add(24*time.Hour))
+			}
+			switch {
+			case tc.triagedAt == nil && sla.TriagedAt != nil:
+				t.Errorf("triaged at %v, want unset", sla.TriagedAt)
+			case tc.triagedAt != nil && (sla.TriagedAt == nil || !sla.TriagedAt.Equal(*tc.triagedAt)):
+				t.Errorf("triaged at %v, want %v", sla.TriagedAt, tc.triagedAt)
+			}
+		})
+	}
+}
+
+func TestFirstResponseAt(t *testing.T) {
+	submitted := time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)
+	comment := func(authorID string, after time.Duration) models.Comment {
+		return models.Comment{ID: primitive.NewObjectIDFromTimestamp(submitted.Add(after)), AuthorID: authorID}
+	}
+	members := map[string]bool{"author": true, "member": true, "admin": true}
+	report := &models.Report{AuthorID: "author"}
+
+	cases := []struct {
+		name     string
+		comments []models.Comment
+		want     time.Duration // after submission, zero when there was no response
+	}{
+		{
+			name: "no comments",
+		},
+		{
+			name:     "only the author",
+			comments: []models.Comment{comment("author", time.Hour)},
+		},
+		{
+			name:     "only outsiders",
+			comments: []models.Comment{comment("outsider", time.Hour)},
+		},
+		{
+			name:     "earliest member",
+			comments: []models.Comment{comment("author", time.Hour), comment("outsider", 2*time.Hour), comment("member", 3*time.Hour), comment("admin", 4*time.Hour)},
+			want:     3 * time.Hour,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := firstResponseAt(report, tc.comments, func(userID string) bool { return members[userID] })
+			switch {
+			case tc.want == 0 && got != nil:
+				t.Errorf("first response at %v, want none", got)
+			case tc.want != 0 && (got == nil || !got.Equal(submitted.Add(tc.want))):
+				t.Errorf("first response at %v, want %v", got, submitted.Add(tc.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// Subscription tracks who watches which reports and projects. Authors,
+// commenters and assignees watch reports automatically, anyone can watch or
+// unwatch by hand. watchers is what notifications fan events out with
+type Subscription struct {
+	DB       databases.SubscriptionDatabase
+	Reports  databases.ReportDatabase
+	Projects databases.ProjectDatabase
+	Members  Membership
+}
+
+// watch subscribes users to a report or project, users already watching it
+// keep their existing subscription
+func (subscription Subscription) watch(ctx context.Context, userIDs []string, targetType, targetID, projectID, reason string) error {
+	for _, userID := range userIDs {
+		if userID == "" {
+			continue
+		}
+
+		_, err := subscription.DB.InsertOne(ctx, &models.Subscription{
+			ID:         primitive.NewObjectID(),
+			UserID:     userID,
+			TargetType: targetType,
+			TargetID:   targetID,
+			ProjectID:  projectID,
+			Reason:     reason,
+			CreatedAt:  time.Now().UTC(),
+		})
+		if err != nil && !errors.Is(err, databases.ErrDuplicateKey) {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchers returns the IDs of the users watching a report or the project it
+// belongs to, each user once. Events about a project alone pass an empty reportID
+func (subscription Subscription) watchers(ctx context.Context, projectID, reportID string) ([]string, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"targetType": models.WatchProject, "targetId": projectID},
+		bson.M{"targetType": models.WatchReport, "targetId": reportID},
+	}}
+
+	subscriptions, err := subscription.DB.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := []string{}
+	for _, s := range subscriptions {
+		if !slices.Contains(userIDs, s.UserID) {
+			userIDs = append(userIDs, s.UserID)
+		}
+	}
+	slices.Sort(userIDs)
+	return userIDs, nil
+}
+
+// move hands the watchers of one report over to another, used when reports are merged
+func (subscription Subscription) move(ctx context.Context, from, to, projectID string) error {
+	subscriptions, err := subscription.DB.Find(ctx, bson.M{"targetType": models.WatchReport, "targetId": from})
+	if err != nil {
+		return err
+	}
+
+	for _, s := range subscriptions {
+		if err := subscription.watch(ctx, []string{s.UserID}, models.WatchReport, to, projectID, s.Reason); err != nil {
+			return err
+		}
+	}
+
+	_, err = subscription.DB.DeleteMany(ctx, bson.M{"targetType": models.WatchReport, "targetId": from})
+	return err
+}
+
+// target looks up the report or project in the request path and returns its
+// ID along with the project it belongs to. When it can't be loaded or the
+// authenticated user can't see it the error response is written and empty IDs
+// are returned
+func (subscription Subscription) target(w http.ResponseWriter, r *http.Request, targetType string) (string, string) {
+	if targetType == models.WatchProject {
+		project := subscription.Members.visibleProject(w, r, mux.Vars(r)["project_id"])
+		if project == nil {
+			return "", ""
+		}
+		return project.ID.Hex(), project.ID.Hex()
+	}
+
+	rID, err := primitive.ObjectIDFromHex(mux.Vars(r)["report_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return "", ""
+	}
+
+	report, _ := subscription.Members.readableReport(w, r, rID)
+	if report == nil {
+		return "", ""
+	}
+	return report.ID.Hex(), report.ProjectID
+}
+
+// WatchReportHandler subscribes the authenticated user to a report
+func (subscription Subscription) WatchReportHandler(w http.ResponseWriter, r *http.Request) {
+	subscription.subscribe(w, r, models.WatchReport)
+}
+
+// UnwatchReportHandler unsubscribes the authenticated user from a report
+func (subscription Subscription) UnwatchReportHandler(w http.ResponseWriter, r *http.Request) {
+	subscription.unsubscribe(w, r, models.WatchReport)
+}
+
+// WatchProjectHandler subscribes the authenticated user to every report of a project
+func (subscription Subscription) WatchProjectHandler(w http.ResponseWriter, r *http.Request) {
+	subscription.subscribe(w, r, models.WatchProject)
+}
+
+// UnwatchProjectHandler unsubscribes the authenticated user from a project
+func (subscription Subscription) UnwatchProjectHandler(w http.ResponseWriter, r *http.Request) {
+	subscription.unsubscribe(w, r, models.WatchProject)
+}
+
+func (subscription Subscription) subscribe(w http.ResponseWriter, r *http.Request, targetType string) {
+	ctx := r.Context()
+
+	targetID, projectID := subscription.target(w, r, targetType)
+	if targetID == "" {
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	if err := subscription.watch(ctx, []string{userID}, targetType, targetID, projectID, models.WatchManual); err != nil {
+		config.ErrorStatus("failed to watch", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	dbResp, err := subscription.DB.FindOne(ctx, bson.M{"userId": userID, "targetType": targetType, "targetId": targetID})
+	if err != nil {
+		config.ErrorStatus("failed to get subscription", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+func (subscription Subscription) unsubscribe(w http.ResponseWriter, r *http.Request, targetType string) {
+	ctx := r.Context()
+
+	targetID := mux.Vars(r)["report_id"]
+	if targetType == models.WatchProject {
+		targetID = mux.Vars(r)["project_id"]
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	dbResp, err := subscription.DB.DeleteOne(ctx, bson.M{"userId": userID, "targetType": targetType, "targetId": targetID})
+	if err != nil {
+		config.ErrorStatus("failed to unwatch", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if dbResp.DeletedCount == 0 {
+		config.ErrorStatus("you are not watching this "+targetType, http.StatusNotFound, w, nil)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// ReportWatchersHandler returns the users watching a report directly, project
+// watchers are listed by ProjectWatchersHandler
+func (subscription Subscription) ReportWatchersHandler(w http.ResponseWriter, r *http.Request) {
+	subscription.targetWatchers(w, r, models.WatchReport)
+}
+
+// ProjectWatchersHandler returns the users watching a project
+func (subscription Subscription) ProjectWatchersHandler(w http.ResponseWriter, r *http.Request) {
+	subscription.targetWatchers(w, r, models.WatchProject)
+}
+
+func (subscription Subscription) targetWatchers(w http.ResponseWriter, r *http.Request, targetType string) {
+	ctx := r.Context()
+
+	targetID, _ := subscription.target(w, r, targetType)
+	if targetID == "" {
+		return
+	}
+
+	oldestFirst := databases.FindOptions{Sort: bson.D{{Key: "createdAt", Value: 1}}}
+	dbResp, err := subscription.DB.Find(ctx, bson.M{"targetType": targetType, "targetId": targetID}, oldestFirst)
+	if err != nil {
+		config.ErrorStatus("failed to get watchers", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// UserSubscriptionsHandler returns everything a user watches, users can only list their own
+func (subscription Subscription) UserSubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID := mux.Vars(r)["user_id"]
+
+	if caller, _ := api.UserIDFromContext(ctx); caller != userID {
+		config.ErrorStatus("you can only list your own subscriptions", http.StatusForbidden, w, nil)
+		return
+	}
+
+	newestFirst := databases.FindOptions{Sort: bson.D{{Key: "createdAt", Value: -1}}}
+	dbResp, err := subscription.DB.FindPage(ctx, bson.M{"userId": userID}, pageFromRequest(r), newestFirst)
+	if err != nil {
+		config.ErrorStatus("failed to get subscriptions", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// defaultSectionLength is how long a section can be when its template doesn't say
+const defaultSectionLength = 5000
+
+// templateSections returns the sections a project's reports must fill in with
+// their length limits filled in. Templates without sections of their own ask
+// for the steps, behaviour and addInfo they describe
+func templateSections(template models.TemplateData) []models.TemplateSection {
+	sections := []models.TemplateSection{
+		{Key: "steps", Label: "Steps to reproduce", Prompt: template.Steps, Required: true},
+		{Key: "behaviour", Label: "Expected and actual behaviour", Prompt: template.Behaviour, Required: true},
+		{Key: "addInfo", Label: "Additional information", Prompt: template.AdditionalInfo},
+	}
+	if len(template.Sections) > 0 {
+		sections = slices.Clone(template.Sections)
+	}
+
+	for i := range sections {
+		if sections[i].MaxLength == 0 {
+			sections[i].MaxLength = defaultSectionLength
+		}
+	}
+	return sections
+}
+
+// validateSections checks report sections against the template, every required
+// section must be filled in and none can be longer than the template allows
+func validateSections(sections []models.TemplateSection, values map[string]string) error {
+	defined := map[string]bool{}
+	for _, section := range sections {
+		defined[section.Key] = true
+
+		value := values[section.Key]
+		if section.Required && strings.TrimSpace(value) == "" {
+			return fmt.Errorf("section %q is required", section.Key)
+		}
+
+		if utf8.RuneCountInString(value) > section.MaxLength {
+			return fmt.Errorf("section %q must be at most %d characters", section.Key, section.MaxLength)
+		}
+	}
+
+	for key := range values {
+		if !defined[key] {
+			return fmt.Errorf("section %q is not part of the project template", key)
+		}
+	}
+	return nil
+}
+
+// TemplateHandler returns the template reports of a project are submitted with,
+// including the sections the frontend should render a form from
+func (project Project) TemplateHandler(w http.ResponseWriter, r *http.Request) {
+	dbResp := project.Members.visibleProject(w, r, mux.Vars(r)["project_id"])
+	if dbResp == nil {
+		return
+	}
+
+	template := dbResp.Template
+	template.Sections = templateSections(template)
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": template, "version": dbResp.TemplateVersion},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// Templates keeps every version of the project templates. A new version is
+// recorded whenever a project's template changes, so reports can be checked
+// against the template they were submitted with
+type Templates struct {
+	DB       databases.TemplateDatabase
+	Projects databases.ProjectDatabase
+}
+
+// sameTemplate reports whether two templates ask for the same thing, a missing
+// list of sections is the same as an empty one
+func sameTemplate(a, b models.TemplateData) bool {
+	return a.Title == b.Title && a.Des == b.Des && a.Steps == b.Steps && a.Behaviour == b.Behaviour &&
+		a.AdditionalInfo == b.AdditionalInfo && slices.Equal(a.Sections, b.Sections)
+}
+
+// version returns the version of the project's current template, recording it
+// as a new version when it differs from the latest one. Templates of projects
+// from before versioning are recorded the first time they're needed
+func (templates Templates) version(ctx context.Context, project *models.Project, userID string) (int, error) {
+	projectID := project.ID.Hex()
+	template := project.Template
+	if template.Sections == nil {
+		template.Sections = []models.TemplateSection{}
+	}
+	newestFirst := databases.FindOptions{Sort: bson.D{{Key: "version", Value: -1}}}
+
+	// another request may record a version between our read and insert, the
+	// unique index turns that into a duplicate key and we look again
+	for range 3 {
+		latest, err := templates.DB.FindOne(ctx, bson.M{"projectId": projectID}, newestFirst)
+		if err != nil && !errors.Is(err, databases.ErrNotFound) {
+			return 0, err
+		}
+
+		version := 1
+		if latest != nil {
+			version = latest.Version
+		}
+
+		if latest == nil || !sameTemplate(latest.Template, project.Template) {
+			if latest != nil {
+				version++
+			}
+
+			_, err := templates.DB.InsertOne(ctx, &models.TemplateVersion{
+				ID:        primitive.NewObjectID(),
+				ProjectID: projectID,
+				Version:   version,
+				Template:  template,
+				CreatedBy: userID,
+				CreatedAt: time.Now().UTC(),
+			})
+			if errors.Is(err, databases.ErrDuplicateKey) {
+				continue
+			}
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		if project.TemplateVersion < version {
+			// never move the project back to an older version another request recorded after ours
+			filter := bson.M{"_id": project.ID, "$or": bson.A{
+				bson.M{"templateVersion": bson.M{"$lt": version}},
+				bson.M{"templateVersion": bson.M{"$exists": false}},
+			}}
+			if _, err := templates.Projects.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"templateVersion": version}}); err != nil {
+				return 0, err
+			}
+			project.TemplateVersion = version
+		}
+		return version, nil
+	}
+	return 0, errors.New("the template kept changing while its version was recorded")
+}
+
+// find returns one version of a project's template, writing the error response
+// and returning nil when the version doesn't exist
+func (templates Templates) find(w http.ResponseWriter, r *http.Request, projectID string, version string) *models.TemplateVersion {
+	number, err := strconv.Atoi(version)
+	if err != nil || number < 1 {
+		config.ErrorStatus("template versions are numbered from 1", http.StatusBadRequest, w, err)
+		return nil
+	}
+
+	dbResp, err := templates.DB.FindOne(r.Context(), bson.M{"projectId": projectID, "version": number})
+	if err != nil {
+		config.ErrorStatus("failed to get template version", http.StatusNotFound, w, err)
+		return nil
+	}
+	return dbResp
+}
+
+// TemplateVersionsHandler lists every version of a project's template, newest first
+func (project Project) TemplateVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["project_id"]
+
+	if project.Members.visibleProject(w, r, projectID) == nil {
+		return
+	}
+
+	newestFirst := databases.FindOptions{Sort: bson.D{{Key: "version", Value: -1}}}
+
+	dbResp, err := project.Templates.DB.FindPage(r.Context(), bson.M{"projectId": projectID}, pageFromRequest(r), newestFirst)
+	if err != nil {
+		config.ErrorStatus("failed to get template versions", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// TemplateVersionHandler returns one version of a project's template, the one
+// a report's templateVersion points at
+func (project Project) TemplateVersionHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["project_id"]
+
+	if project.Members.visibleProject(w, r, projectID) == nil {
+		return
+	}
+
+	dbResp := project.Templates.find(w, r, projectID, mux.Vars(r)["version"])
+	if dbResp == nil {
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// TemplateDiffHandler returns what changed in a project's template between the
+// versions in the from and to query parameters
+func (project Project) TemplateDiffHandler(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["project_id"]
+
+	if project.Members.visibleProject(w, r, projectID) == nil {
+		return
+	}
+
+	from := project.Templates.find(w, r, projectID, r.URL.Query().Get("from"))
+	if from == nil {
+		return
+	}
+
+	to := project.Templates.find(w, r, projectID, r.URL.Query().Get("to"))
+	if to == nil {
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": diffTemplates(from, to)},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// diffTemplates compares two template versions. Sections are compared as
+// reports see them, so templates without sections of their own compare their
+// steps, behaviour and addInfo sections
+func diffTemplates(from, to *models.TemplateVersion) models.TemplateDiff {
+	diff := models.TemplateDiff{
+		From:     from.Version,
+		To:       to.Version,
+		Fields:   []models.TemplateFieldChange{},
+		Added:    []models.TemplateSection{},
+		Removed:  []models.TemplateSection{},
+		Modified: []models.TemplateSectionChange{},
+	}
+
+	fields := []models.TemplateFieldChange{
+		{Field: "title", From: from.Template.Title, To: to.Template.Title},
+		{Field: "des", From: from.Template.Des, To: to.Template.Des},
+		{Field: "steps", From: from.Template.Steps, To: to.Template.Steps},
+		{Field: "behaviour", From: from.Template.Behaviour, To: to.Template.Behaviour},
+		{Field: "addInfo", From: from.Template.AdditionalInfo, To: to.Template.AdditionalInfo},
+	}
+	for _, field := range fields {
+		if field.From != field.To {
+			diff.Fields = append(diff.Fields, field)
+		}
+	}
+
+	before := map[string]models.TemplateSection{}
+	for _, section := range templateSections(from.Template) {
+		before[section.Key] = section
+	}
+
+	after := templateSections(to.Template)
+	for _, section := range after {
+		old, ok := before[section.Key]
+		if !ok {
+			diff.Added = append(diff.Added, section)
+			continue
+		}
+		if old != section {
+			diff.Modified = append(diff.Modified, models.TemplateSectionChange{Key: section.Key, From: old, To: section})
+		}
+	}
+
+	for _, section := range templateSections(from.Template) {
+		if !slices.ContainsFunc(after, func(s models.TemplateSection) bool { return s.Key == section.Key }) {
+			diff.Removed = append(diff.Removed, section)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+func TestDiffTemplates(t *testing.T) {
+	base := models.TemplateData{Title: "Bug", Des: "d", Steps: "What did you do?", Behaviour: "What happened?"}
+	withSections := func(sections ...models.TemplateSection) models.TemplateData {
+		template := base
+		template.Sections = sections
+		return template
+	}
+	env := models.TemplateSection{Key: "env", Label: "Environment", Required: true}
+	logs := models.TemplateSection{Key: "logs", Label: "Logs"}
+
+	cases := []struct {
+		name     string
+		from, to models.TemplateData
+		fields   []string // names of the changed fields
+		added    []string // keys of the added sections
+		removed  []string
+		modified []string
+	}{
+		{
+			name: "unchanged",
+			from: withSections(env, logs),
+			to:   withSections(env, logs),
+		},
+		{
+			name:  "added",
+			from:  withSections(env),
+			to:    withSections(env, logs),
+			added: []string{"logs"},
+		},
+		{
+			name:    "removed",
+			from:    withSections(env, logs),
+			to:      withSections(logs),
+			removed: []string{"env"},
+		},
+		{
+			name:     "changed",
+			from:     withSections(env, logs),
+			to:       withSections(models.TemplateSection{Key: "env", Label: "Environment"}, logs),
+			modified: []string{"env"},
+		},
+		{
+			// the default sections take their prompts from the steps, behaviour and addInfo fields
+			name:     "default sections",
+			from:     base,
+			to:       models.TemplateData{Title: "Bug", Des: "d", Steps: "How do we reproduce it?", Behaviour: "What happened?"},
+			fields:   []string{"steps"},
+			modified: []string{"steps"},
+		},
+		{
+			// dropping the default sections for sections of its own
+			name:    "own sections",
+			from:    base,
+			to:      withSections(env),
+			added:   []string{"env"},
+			removed: []string{"steps", "behaviour", "addInfo"},
+		},
+	}
+
+	sectionKeys := func(sections []models.TemplateSection) []string {
+		keys := []string{}
+		for _, section := range sections {
+			keys = append(keys, section.Key)
+		}
+		return keys
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			diff := diffTemplates(&models.TemplateVersion{Version: 1, Template: tc.from}, &models.TemplateVersion{Version: 2, Template: tc.to})
+			if diff.From != 1 || diff.To != 2 {
+				t.Errorf("diff from %d to %d, want from 1 to 2", diff.From, diff.To)
+			}
+
+			fields := []string{}
+			for _, field := range diff.Fields {
+				fields = append(fields, field.Field)
+			}
+			modified := []string{}
+			for _, change := range diff.Modified {
+				modified = append(modified, change.Key)
+			}
+
+			for _, check := range []struct {
+				what      string
+				got, want []string
+			}{
+				{"fields", fields, tc.fields},
+				{"added", sectionKeys(diff.Added), tc.added},
+				{"removed", sectionKeys(diff.Removed), tc.removed},
+				{"modified", modified, tc.modified},
+			} {
+				if !slices.Equal(check.got, check.want) && len(check.got)+len(check.want) > 0 {
+					t.Errorf("%s %v, want %v", check.what, check.got, check.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	gocvss31 "github.com/pandatix/go-cvss/31"
+	gocvss40 "github.com/pandatix/go-cvss/40"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// scoreVector parses a CVSS v3.1 or v4.0 vector and returns its version and score,
+// v3.1 vectors are given their base score
+func scoreVector(vector string) (string, float64, error) {
+	switch {
+	case strings.HasPrefix(vector, "CVSS:3.1/"):
+		cvss, err := gocvss31.ParseVector(vector)
+		if err != nil {
+			return "", 0, err
+		}
+		return "3.1", cvss.BaseScore(), nil
+	case strings.HasPrefix(vector, "CVSS:4.0/"):
+		cvss, err := gocvss40.ParseVector(vector)
+		if err != nil {
+			return "", 0, err
+		}
+		return "4.0", cvss.Score(), nil
+	default:
+		return "", 0, errors.New("only CVSS:3.1 and CVSS:4.0 vectors are supported")
+	}
+}
+
+// scoreLevel returns the named severity level of a CVSS score
+// using the qualitative rating scale shared by v3.1 and v4.0
+func scoreLevel(score float64) string {
+	switch {
+	case score >= 9.0:
+		return "critical"
+	case score >= 7.0:
+		return "high"
+	case score >= 4.0:
+		return "medium"
+	case score >= 0.1:
+		return "low"
+	default:
+		return "none"
+	}
+}
+
+// TriageReportHandler sets a report's severity, either from a named level or by
+// scoring a CVSS vector. Only admins of the report's project can triage it
+func (report Report) TriageReportHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.ReportTriageDetails
+
+	reportID := mux.Vars(r)["report_id"]
+
+	rID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	if (details.Level == "") == (details.Vector == "") {
+		config.ErrorStatus("exactly one of level or vector is required", http.StatusBadRequest, w, nil)
+		return
+	}
+
+	current, err := report.DB.FindByID(ctx, rID)
+	if err != nil {
+		config.ErrorStatus("failed to get report by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	project := projectAdmin(ctx, w, r, report.Members.Projects, current.ProjectID)
+	if project == nil || archived(w, project) {
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	triage := models.Triage{
+		Level:     details.Level,
+		TriagedBy: userID,
+		TriagedAt: time.Now().UTC(),
+	}
+
+	if details.Vector != "" {
+		version, score, err := scoreVector(details.Vector)
+		if err != nil {
+			config.ErrorStatus(fmt.Sprintf("invalid CVSS vector %q", details.Vector), http.StatusBadRequest, w, err)
+			return
+		}
+		triage.Level = scoreLevel(score)
+		triage.Vector = details.Vector
+		triage.Version = version
+		triage.Score = &score
+	}
+
+	set := bson.M{"severity": models.SeverityLevels[triage.Level], "triage": triage}
+
+	// the first triage meets the triage deadline, deadlines follow the new severity
+	if project.SLA != nil {
+		current.Severity = models.SeverityLevels[triage.Level]
+		sla := slaDeadlines(project.SLA, current)
+		if sla.TriagedAt == nil {
+			sla.TriagedAt = &triage.TriagedAt
+		}
+		set["sla"] = sla
+	}
+
+	update := bson.M{"$set": set}
+	dbResp, err := report.DB.UpdateByID(ctx, rID, update)
+	if err != nil {
+		config.ErrorStatus("the report could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("report not found", http.StatusNotFound, w, nil)
+		return
+	}
+
+	if err := report.Reputation.sync(ctx, reportID); err != nil {
+		config.ErrorStatus("failed to update reputation", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": triage},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import "testing"
+
+func TestScoreVector(t *testing.T) {
+	cases := []struct {
+		name    string
+		vector  string
+		version string
+		score   float64
+		wantErr bool
+	}{
+		{
+			name:    "v3.1 critical",
+			vector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+			version: "3.1",
+			score:   9.8,
+		},
+		{
+			name:    "v3.1 maximum",
+			vector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H",
+			version: "3.1",
+			score:   10.0,
+		},
+		{
+			name:    "v3.1 no impact",
+			vector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N",
+			version: "3.1",
+			score:   0.0,
+		},
+		{
+			name:    "v4.0",
+			vector:  "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N",
+			version: "4.0",
+			score:   9.3,
+		},
+		{
+			name:    "v3.0 is not supported",
+			vector:  "CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+			wantErr: true,
+		},
+		{
+			name:    "missing metric",
+			vector:  "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			version, score, err := scoreVector(tc.vector)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("error %v, want error %v", err, tc.wantErr)
+			}
+			if version != tc.version || score != tc.score {
+				t.Errorf("scored %s %v, want %s %v", version, score, tc.version, tc.score)
+			}
+		})
+	}
+}
+
+func TestScoreLevel(t *testing.T) {
+	// each level starts on the lower bound of its range in the qualitative rating scale
+	cases := []struct {
+		score float64
+		level string
+	}{
+		{0.0, "none"},
+		{0.1, "low"},
+		{3.9, "low"},
+		{4.0, "medium"},
+		{6.9, "medium"},
+		{7.0, "high"},
+		{8.9, "high"},
+		{9.0, "critical"},
+		{10.0, "critical"},
+	}
+
+	for _, tc := range cases {
+		if level := scoreLevel(tc.score); level != tc.level {
+			t.Errorf("score %v is %q, want %q", tc.score, level, tc.level)
+		}
+	}
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
 	"time"
@@ -18,8 +17,13 @@ import (
 )
 
 type User struct {
-	DB   databases.UserDatabase
-	Auth *auth.AuthService
+	DB       databases.UserDatabase
+	Auth     *auth.AuthService
+	Members  Membership
+	Watchers Subscription
+	Votes    Vote
+
+	Reputation Reputation
 }
 
 // temp
@@ -36,7 +40,7 @@ func (user User) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Lookup user
-	dbResp, err := user.DB.FindOne(context.Background(), bson.M{"email": req.Email})
+	dbResp, err := user.DB.FindOne(r.Context(), bson.M{"email": req.Email})
 	if err != nil {
 		config.ErrorStatus("failed to get user by email", http.StatusNotFound, w, err)
 		return
@@ -95,7 +99,7 @@ func (user User) UserByObjectIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbResp, err := user.DB.FindOne(context.Background(), bson.M{"_id": uID})
+	dbResp, err := user.DB.FindByID(r.Context(), uID)
 	if err != nil {
 		config.ErrorStatus("failed to get user by ID", http.StatusNotFound, w, err)
 		return
@@ -120,9 +124,8 @@ func (user User) UserByObjectIDHandler(w http.ResponseWriter, r *http.Request) {
 
 // Create new user
 func (user User) NewUserHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx := r.Context()
 	var details models.UserDetails // Json data will represent the user details model
-	defer cancel()
 
 	// validate the request body
 	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
@@ -146,7 +149,7 @@ func (user User) NewUserHandler(w http.ResponseWriter, r *http.Request) {
 		Password:   details.Password,
 	}
 
-	result, err := user.DB.InsertOne(ctx, newUser)
+	result, err := user.DB.InsertOne(ctx, &newUser)
 	if err != nil {
 		config.ErrorStatus("failed to insert user", http.StatusBadRequest, w, err)
 		return
@@ -171,9 +174,8 @@ func (user User) NewUserHandler(w http.ResponseWriter, r *http.Request) {
 
 // UpdateUserHandler updates the attributes of a user
 func (user User) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx := r.Context()
 	var newDetails models.UserUpdateDetails
-	defer cancel()
 
 	userID := mux.Vars(r)["user_id"]
 
@@ -197,9 +199,9 @@ func (user User) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	update := util.BuildUpdate(newDetails)
 
-	dbResp, err := user.DB.UpdateOne(
+	dbResp, err := user.DB.UpdateByID(
 		ctx,
-		bson.M{"_id": uID},
+		uID,
 		bson.M{"$set": update},
 	)
 
@@ -208,6 +210,11 @@ func (user User) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("User not found", http.StatusNotFound, w, nil)
+		return
+	}
+
 	b, err := json.Marshal(
 		models.DataResponse{
 			Status:  http.StatusOK,
@@ -226,8 +233,7 @@ func (user User) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (user User) DeleteUserByIdHandler(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	ctx := r.Context()
 
 	userID := mux.Vars(r)["user_id"]
 
@@ -238,17 +244,48 @@ func (user User) DeleteUserByIdHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbResp, err := user.DB.DeleteOne(ctx, bson.M{"_id": uID})
+	// projects are never left without an owner
+	owner, err := user.Members.Projects.Exists(ctx, bson.M{"ownerId": userID})
+	if err != nil {
+		config.ErrorStatus("failed to check owned projects", http.StatusInternalServerError, w, err)
+		return
+	}
+	if owner {
+		config.ErrorStatus("the user owns projects, transfer them first", http.StatusConflict, w, nil)
+		return
+	}
+
+	dbResp, err := user.DB.DeleteByID(ctx, uID)
 	if err != nil {
 		config.ErrorStatus("failed to delete user", http.StatusNotFound, w, err)
 		return
 	}
 
-	if dbResp.Dr.DeletedCount == 0 {
+	if dbResp.DeletedCount == 0 {
 		config.ErrorStatus("User not found", http.StatusNotFound, w, nil)
 		return
 	}
 
+	if err := user.Members.removeUser(ctx, userID); err != nil {
+		config.ErrorStatus("failed to remove user memberships", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if _, err := user.Watchers.DB.DeleteMany(ctx, bson.M{"userId": userID}); err != nil {
+		config.ErrorStatus("failed to remove user subscriptions", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if err := user.Votes.removeUser(ctx, userID); err != nil {
+		config.ErrorStatus("failed to remove user votes", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if _, err := user.Reputation.DB.DeleteMany(ctx, bson.M{"userId": userID}); err != nil {
+		config.ErrorStatus("failed to remove user reputation", http.StatusInternalServerError, w, err)
+		return
+	}
+
 	b, err := json.Marshal(
 		models.DataResponse{
 			Status:  http.StatusOK,
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// reader is what a user is allowed to read. Reporters, assignees and project
+// admins read reports in full, anyone else only reads disclosed reports of
+// projects they can see and with the disclosure's redactions applied
+type reader struct {
+	userID      string
+	administers []string // projects the user owns or administers
+	hidden      []string // private projects the user is not a member of
+}
+
+// reader works out what the authenticated user is allowed to read
+func (membership Membership) reader(ctx context.Context) (reader, error) {
+	userID, _ := api.UserIDFromContext(ctx)
+	return membership.readerOf(ctx, userID)
+}
+
+// readerOf works out what a user is allowed to read
+func (membership Membership) readerOf(ctx context.Context, userID string) (reader, error) {
+	re := reader{userID: userID, administers: []string{}, hidden: []string{}}
+
+	administered, err := membership.Projects.Find(ctx, bson.M{"$or": bson.A{bson.M{"ownerId": userID}, bson.M{"adminIds": userID}}})
+	if err != nil {
+		return re, err
+	}
+	for _, project := range administered {
+		re.administers = append(re.administers, project.ID.Hex())
+	}
+
+	private, err := membership.Projects.Find(ctx, bson.M{"visibility": models.ProjectPrivate})
+	if err != nil || len(private) == 0 {
+		return re, err
+	}
+
+	memberships, err := membership.DB.Find(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return re, err
+	}
+	for _, project := range private {
+		projectID := project.ID.Hex()
+		member := slices.ContainsFunc(memberships, func(m models.Membership) bool { return m.ProjectID == projectID })
+		if !member && !slices.Contains(re.administers, projectID) {
+			re.hidden = append(re.hidden, projectID)
+		}
+	}
+	return re, nil
+}
+
+// filter matches the reports the user can read
+func (re reader) filter() bson.M {
+	hidden, administers := bson.A{}, bson.A{}
+	for _, projectID := range re.hidden {
+		hidden = append(hidden, projectID)
+	}
+	for _, projectID := range re.administers {
+		administers = append(administers, projectID)
+	}
+
+	return bson.M{"$or": bson.A{
+		bson.M{"visibility": models.ReportPublic, "projectId": bson.M{"$nin": hidden}},
+		bson.M{"author": re.userID},
+		bson.M{"assigneeIds": re.userID},
+		bson.M{"projectId": bson.M{"$in": administers}},
+	}}
+}
+
+// privileged reports whether the user reads a report in full
+func (re reader) privileged(report *models.Report) bool {
+	return re.userID != "" && (report.AuthorID == re.userID ||
+		slices.Contains(report.AssigneeIDs, re.userID) ||
+		slices.Contains(re.administers, report.ProjectID))
+}
+
+// canRead reports whether the user can read a report at all
+func (re reader) canRead(report *models.Report) bool {
+	if re.privileged(report) {
+		return true
+	}
+	return report.Visibility == models.ReportPublic && !slices.Contains(re.hidden, report.ProjectID)
+}
+
+// present redacts the reports the user doesn't read in full
+func (re reader) present(reports []models.Report) {
+	for i := range reports {
+		if !re.privileged(&reports[i]) {
+			redact(&reports[i])
+		}
+	}
+}
+
+// redactor replaces the redactions of a report's disclosure
+func redactor(report *models.Report) *strings.Replacer {
+	pairs := []string{}
+	if report.Disclosure != nil {
+		for _, text := range report.Disclosure.Redactions {
+			pairs = append(pairs, text, models.Redacted)
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// redact applies a report's redactions to what it says and drops the redactions
+// themselves, which would give away what they hide
+func redact(report *models.Report) {
+	replacer := redactor(report)
+	report.Title = replacer.Replace(report.Title)
+	report.Des = replacer.Replace(report.Des)
+
+	sections := map[string]string{}
+	for key, value := range report.Sections {
+		sections[key] = replacer.Replace(value)
+	}
+	report.Sections = sections
+
+	if report.Disclosure != nil {
+		disclosure := *report.Disclosure
+		disclosure.Redactions = nil
+		report.Disclosure = &disclosure
+	}
+}
+
+// readableReport loads a report and checks the authenticated user can read it,
+// returning what they can read. Reports they can't read are reported as not
+// found so their existence isn't given away. When it can't be read the error
+// response is written and nil is returned
+func (membership Membership) readableReport(w http.ResponseWriter, r *http.Request, rID primitive.ObjectID) (*models.Report, reader) {
+	ctx := r.Context()
+
+	current, err := membership.Reports.FindByID(ctx, rID)
+	if err != nil {
+		config.ErrorStatus("failed to get report by ID", http.StatusNotFound, w, err)
+		return nil, reader{}
+	}
+
+	re, err := membership.reader(ctx)
+	if err != nil {
+		config.ErrorStatus("failed to check report visibility", http.StatusInternalServerError, w, err)
+		return nil, reader{}
+	}
+
+	if !re.canRead(current) {
+		config.ErrorStatus("failed to get report by ID", http.StatusNotFound, w, nil)
+		return nil, reader{}
+	}
+	return current, re
+}
+
+// editableReport loads a report the authenticated user can change, only its
+// reporter and admins of its project can. When it can't be changed the error
+// response is written and nil is returned
+func (membership Membership) editableReport(w http.ResponseWriter, r *http.Request, rID primitive.ObjectID) *models.Report {
+	current, re := membership.readableReport(w, r, rID)
+	if current == nil {
+		return nil
+	}
+
+	if current.AuthorID != re.userID && !slices.Contains(re.administers, current.ProjectID) {
+		config.ErrorStatus("only the reporter and project admins can change the report", http.StatusForbidden, w, nil)
+		return nil
+	}
+	return current
+}
+
+// visibleProject loads a project and checks the authenticated user can see it.
+// Private projects are reported as not found to anyone but their members. When
+// it can't be seen the error response is written and nil is returned
+func (membership Membership) visibleProject(w http.ResponseWriter, r *http.Request, projectID string) *models.Project {
+	ctx := r.Context()
+
+	pID, err := primitive.ObjectIDFromHex(projectID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return nil
+	}
+
+	project, err := membership.Projects.FindByID(ctx, pID)
+	if err != nil {
+		config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, err)
+		return nil
+	}
+
+	if project.Visibility != models.ProjectPrivate {
+		return project
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	roles, err := membership.roles(ctx, project, userID)
+	if err != nil {
+		config.ErrorStatus("failed to get project roles", http.StatusInternalServerError, w, err)
+		return nil
+	}
+	if len(roles) == 0 {
+		config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, nil)
+		return nil
+	}
+	return project
+}
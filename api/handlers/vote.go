@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// Vote lets users say a report affects them too. The votes collection is the
+// source of truth, Report.Votes is a count of it kept for sorting
+type Vote struct {
+	DB      databases.VoteDatabase
+	Reports databases.ReportDatabase
+	Users   databases.UserDatabase
+	Members Membership
+}
+
+// VoteReportHandler adds the authenticated user's vote to a report
+func (vote Vote) VoteReportHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rID, err := primitive.ObjectIDFromHex(mux.Vars(r)["report_id"])
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	current, _ := vote.Members.readableReport(w, r, rID)
+	if current == nil || vote.Members.readOnly(w, r, current.ProjectID) {
+		return
+	}
+
+	if current.DuplicateOf != "" {
+		config.ErrorStatus("the report was merged into "+current.DuplicateOf+", vote on that one instead", http.StatusConflict, w, nil)
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	if current.AuthorID == userID {
+		config.ErrorStatus("you cannot vote on your own report", http.StatusBadRequest, w, nil)
+		return
+	}
+
+	newVote := models.Vote{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		ReportID:  current.ID.Hex(),
+		ProjectID: current.ProjectID,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	result, err := vote.DB.InsertOne(ctx, &newVote)
+	if errors.Is(err, databases.ErrDuplicateKey) {
+		config.ErrorStatus("you have already voted on this report", http.StatusConflict, w, err)
+		return
+	}
+	if err != nil {
+		config.ErrorStatus("failed to insert vote", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if _, err := vote.Reports.UpdateByID(ctx, rID, bson.M{"$inc": bson.M{"votes": 1}}); err != nil {
+		config.ErrorStatus("failed to count vote", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusCreated,
+			Message: "success",
+			Data:    map[string]any{"result": result},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(b)
+}
+
+// UnvoteReportHandler takes back the authenticated user's vote on a report
+func (vote Vote) UnvoteReportHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	reportID := mux.Vars(r)["report_id"]
+
+	rID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	current, err := vote.Reports.FindByID(ctx, rID)
+	if err != nil {
+		config.ErrorStatus("failed to get report by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	if vote.Members.readOnly(w, r, current.ProjectID) {
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	dbResp, err := vote.DB.DeleteOne(ctx, bson.M{"userId": userID, "reportId": reportID})
+	if err != nil {
+		config.ErrorStatus("failed to delete vote", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if dbResp.DeletedCount == 0 {
+		config.ErrorStatus("you have not voted on this report", http.StatusNotFound, w, nil)
+		return
+	}
+
+	if _, err := vote.Reports.UpdateByID(ctx, rID, bson.M{"$inc": bson.M{"votes": -1}}); err != nil {
+		config.ErrorStatus("failed to count vote", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// ReportVotersHandler returns a page of the users who voted on a report, most recent first
+func (vote Vote) ReportVotersHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	reportID := mux.Vars(r)["report_id"]
+
+	rID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+	if current, _ := vote.Members.readableReport(w, r, rID); current == nil {
+		return
+	}
+
+	newestFirst := databases.FindOptions{Sort: bson.D{{Key: "createdAt", Value: -1}}}
+	votes, err := vote.DB.FindPage(ctx, bson.M{"reportId": reportID}, pageFromRequest(r), newestFirst)
+	if err != nil {
+		config.ErrorStatus("failed to get voters", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	userIDs := bson.A{}
+	for _, v := range votes.Items {
+		if uID, err := primitive.ObjectIDFromHex(v.UserID); err == nil {
+			userIDs = append(userIDs, uID)
+		}
+	}
+
+	users, err := vote.Users.Find(ctx, bson.M{"_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		config.ErrorStatus("failed to get voters", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	usersByID := map[string]models.User{}
+	for _, u := range users {
+		usersByID[u.ID.Hex()] = u
+	}
+
+	voters := databases.PageResult[models.Voter]{Items: []models.Voter{}, Total: votes.Total, Page: votes.Page, PageSize: votes.PageSize}
+	for _, v := range votes.Items {
+		if u, ok := usersByID[v.UserID]; ok {
+			voters.Items = append(voters.Items, models.Voter{User: u, VotedAt: v.CreatedAt})
+		}
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": voters},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// recount sets a report's vote count from the votes collection
+func (vote Vote) recount(ctx context.Context, reportID string) error {
+	rID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		return err
+	}
+
+	count, err := vote.DB.Count(ctx, bson.M{"reportId": reportID})
+	if err != nil {
+		return err
+	}
+
+	_, err = vote.Reports.UpdateByID(ctx, rID, bson.M{"$set": bson.M{"votes": count}})
+	return err
+}
+
+// move hands the votes of one report over to another, used when reports are
+// merged. Users who voted on both keep a single vote
+func (vote Vote) move(ctx context.Context, from, to string) error {
+	votes, err := vote.DB.Find(ctx, bson.M{"reportId": from})
+	if err != nil {
+		return err
+	}
+
+	for _, v := range votes {
+		v.ID, v.ReportID = primitive.NewObjectID(), to
+		if _, err := vote.DB.InsertOne(ctx, &v); err != nil && !errors.Is(err, databases.ErrDuplicateKey) {
+			return err
+		}
+	}
+
+	if _, err := vote.DB.DeleteMany(ctx, bson.M{"reportId": from}); err != nil {
+		return err
+	}
+	if err := vote.recount(ctx, from); err != nil {
+		return err
+	}
+	return vote.recount(ctx, to)
+}
+
+// removeUser deletes every vote of a user and recounts the reports they voted on
+func (vote Vote) removeUser(ctx context.Context, userID string) error {
+	votes, err := vote.DB.Find(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return err
+	}
+
+	if _, err := vote.DB.DeleteMany(ctx, bson.M{"userId": userID}); err != nil {
+		return err
+	}
+
+	for _, v := range votes {
+		if err := vote.recount(ctx, v.ReportID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
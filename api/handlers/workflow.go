@@ -0,0 +1,377 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/api"
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/databases"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// defaultWorkflow is used by projects that have not defined their own
+func defaultWorkflow() models.Workflow {
+	admins := []string{models.RoleAdmin}
+	open := []string{"new", "triaged", "in_progress"}
+	closed := []string{"resolved", "wont_fix", "duplicate"}
+
+	return models.Workflow{
+		InitialState: "new",
+		States: []models.WorkflowState{
+			{Name: "new"},
+			{Name: "triaged"},
+			{Name: "in_progress"},
+			{Name: "resolved", Terminal: true},
+			{Name: "wont_fix", Terminal: true},
+			{Name: "duplicate", Terminal: true},
+		},
+		Transitions: []models.WorkflowTransition{
+			{From: []string{"new"}, To: "triaged", Roles: admins},
+			{From: []string{"triaged"}, To: "in_progress", Roles: []string{models.RoleAdmin, models.RoleMember}},
+			{From: open, To: "resolved", Roles: admins},
+			{From: open, To: "wont_fix", Roles: admins},
+			{From: open, To: "duplicate", Roles: admins},
+			{From: closed, To: "triaged", Roles: []string{models.RoleAdmin, models.RoleReporter}},
+		},
+	}
+}
+
+// projectWorkflow returns the workflow a project's reports follow
+func projectWorkflow(project *models.Project) models.Workflow {
+	if project.Workflow == nil {
+		return defaultWorkflow()
+	}
+	return *project.Workflow
+}
+
+// workflowState looks up a state by name
+func workflowState(workflow models.Workflow, name string) (models.WorkflowState, bool) {
+	for _, state := range workflow.States {
+		if state.Name == name {
+			return state, true
+		}
+	}
+	return models.WorkflowState{}, false
+}
+
+// reportState returns the state a report is in, reports created before
+// workflows existed are treated as being in the initial state
+func reportState(workflow models.Workflow, report *models.Report) string {
+	if report.State == "" {
+		return workflow.InitialState
+	}
+	return report.State
+}
+
+// validateWorkflow checks a workflow only refers to states it defines
+func validateWorkflow(workflow models.Workflow) error {
+	names := map[string]bool{}
+	for _, state := range workflow.States {
+		if names[state.Name] {
+			return fmt.Errorf("state %q is defined more than once", state.Name)
+		}
+		names[state.Name] = true
+	}
+
+	if !names[workflow.InitialState] {
+		return fmt.Errorf("initial state %q is not defined", workflow.InitialState)
+	}
+
+	for _, transition := range workflow.Transitions {
+		if !names[transition.To] {
+			return fmt.Errorf("transition to undefined state %q", transition.To)
+		}
+		for _, from := range transition.From {
+			if !names[from] {
+				return fmt.Errorf("transition from undefined state %q", from)
+			}
+		}
+	}
+	return nil
+}
+
+// WorkflowHandler returns the workflow a project's reports follow
+func (project Project) WorkflowHandler(w http.ResponseWriter, r *http.Request) {
+	dbResp := project.Members.visibleProject(w, r, mux.Vars(r)["project_id"])
+	if dbResp == nil {
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": projectWorkflow(dbResp)},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// UpdateWorkflowHandler replaces a project's workflow, only project admins can change it.
+// States reports are still in can't be dropped, the reports have to be moved out first
+func (project Project) UpdateWorkflowHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var workflow models.Workflow
+
+	projectID := mux.Vars(r)["project_id"]
+
+	current := projectAdmin(ctx, w, r, project.DB, projectID)
+	if current == nil || archived(w, current) {
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&workflow); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&workflow); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	if err := validateWorkflow(workflow); err != nil {
+		config.ErrorStatus("invalid workflow", http.StatusBadRequest, w, err)
+		return
+	}
+
+	occupied, err := project.occupiedStates(ctx, projectWorkflow(current), workflow, projectID)
+	if err != nil {
+		config.ErrorStatus("failed to check report states", http.StatusInternalServerError, w, err)
+		return
+	}
+	if len(occupied) > 0 {
+		err := fmt.Errorf("reports are still in %s", strings.Join(occupied, ", "))
+		config.ErrorStatus("the workflow drops states reports are in, move them out first", http.StatusConflict, w, err)
+		return
+	}
+
+	pID, _ := primitive.ObjectIDFromHex(projectID)
+	dbResp, err := project.DB.UpdateByID(ctx, pID, bson.M{"$set": bson.M{"workflow": workflow}})
+	if err != nil {
+		config.ErrorStatus("the workflow could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": dbResp},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
+
+// occupiedStates returns the states of the current workflow that the new one
+// drops and that reports of the project are still in
+func (project Project) occupiedStates(ctx context.Context, current, workflow models.Workflow, projectID string) ([]string, error) {
+	dropped := []string{}
+	for _, state := range current.States {
+		if _, ok := workflowState(workflow, state.Name); !ok {
+			dropped = append(dropped, state.Name)
+		}
+	}
+	if len(dropped) == 0 {
+		return []string{}, nil
+	}
+
+	reports, err := project.Members.Reports.Find(ctx, bson.M{"projectId": projectID}, databases.FindOptions{Projection: bson.M{"state": 1}})
+	if err != nil {
+		return nil, err
+	}
+
+	occupied := []string{}
+	for _, report := range reports {
+		state := reportState(current, &report)
+		if slices.Contains(dropped, state) && !slices.Contains(occupied, state) {
+			occupied = append(occupied, state)
+		}
+	}
+	slices.Sort(occupied)
+	return occupied, nil
+}
+
+// TransitionReportHandler moves a report to another state of its project's
+// workflow if the caller holds a role allowed to make that move
+func (report Report) TransitionReportHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var details models.ReportTransitionDetails
+
+	reportID := mux.Vars(r)["report_id"]
+
+	rID, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		config.ErrorStatus("failed to get objectID from Hex", http.StatusBadRequest, w, err)
+		return
+	}
+
+	// validate the request body
+	if err := json.NewDecoder(r.Body).Decode(&details); err != nil {
+		config.ErrorStatus("failed to unpack request body", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	// use the validator library to validate required fields
+	if validationErr := validate.Struct(&details); validationErr != nil {
+		config.ErrorStatus("invalid request body", http.StatusBadRequest, w, validationErr)
+		return
+	}
+
+	// reports the caller can't read look like they don't exist
+	current, _ := report.Members.readableReport(w, r, rID)
+	if current == nil {
+		return
+	}
+
+	pID, err := primitive.ObjectIDFromHex(current.ProjectID)
+	if err != nil {
+		config.ErrorStatus("report has an invalid project ID", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	project, err := report.Members.Projects.FindByID(ctx, pID)
+	if err != nil {
+		config.ErrorStatus("failed to get project by ID", http.StatusNotFound, w, err)
+		return
+	}
+
+	if archived(w, project) {
+		return
+	}
+
+	workflow := projectWorkflow(project)
+	from := reportState(workflow, current)
+
+	to, ok := workflowState(workflow, details.To)
+	if !ok {
+		config.ErrorStatus(fmt.Sprintf("state %q is not part of the project workflow", details.To), http.StatusBadRequest, w, nil)
+		return
+	}
+
+	userID, _ := api.UserIDFromContext(ctx)
+	roles, err := report.Members.roles(ctx, project, userID)
+	if err != nil {
+		config.ErrorStatus("failed to get project roles", http.StatusInternalServerError, w, err)
+		return
+	}
+	if current.AuthorID == userID {
+		roles = append(roles, models.RoleReporter)
+	}
+
+	allowed, exists := false, false
+	for _, transition := range workflow.Transitions {
+		if transition.To != to.Name || !slices.Contains(transition.From, from) {
+			continue
+		}
+		exists = true
+		for _, role := range transition.Roles {
+			if slices.Contains(roles, role) {
+				allowed = true
+			}
+		}
+	}
+
+	if !exists {
+		config.ErrorStatus(fmt.Sprintf("reports cannot move from %q to %q", from, to.Name), http.StatusConflict, w, nil)
+		return
+	}
+	if !allowed {
+		config.ErrorStatus("you are not allowed to perform this transition", http.StatusForbidden, w, nil)
+		return
+	}
+
+	if to.Terminal && details.Reason == "" {
+		config.ErrorStatus(fmt.Sprintf("a resolution reason is required to move to %q", to.Name), http.StatusBadRequest, w, nil)
+		return
+	}
+
+	change := models.StateChange{
+		From:   from,
+		To:     to.Name,
+		UserID: userID,
+		Reason: details.Reason,
+		At:     time.Now().UTC(),
+	}
+
+	set := bson.M{"state": to.Name, "resolved": to.Terminal}
+	update := bson.M{"$set": set, "$push": bson.M{"history": change}}
+	if to.Terminal {
+		set["resolution"] = details.Reason
+		// resolving a report meets its resolve deadline
+		if current.SLA != nil && current.SLA.ResolvedAt == nil {
+			set["sla.resolvedAt"] = change.At
+		}
+	} else {
+		unset := bson.M{"resolution": ""}
+		// reopening a report puts its resolve deadline back in play
+		if current.SLA != nil {
+			unset["sla.resolvedAt"] = ""
+		}
+		update["$unset"] = unset
+	}
+
+	// only apply the change if nobody moved the report since we read it
+	stateFilter := any(current.State)
+	if current.State == "" {
+		stateFilter = bson.M{"$in": bson.A{"", nil}}
+	}
+
+	dbResp, err := report.DB.UpdateOne(ctx, bson.M{"_id": rID, "state": stateFilter}, update)
+	if err != nil {
+		config.ErrorStatus("the report could not be updated", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	if dbResp.MatchedCount == 0 {
+		config.ErrorStatus("the report was changed by someone else, try again", http.StatusConflict, w, nil)
+		return
+	}
+
+	if err := report.Reputation.sync(ctx, reportID); err != nil {
+		config.ErrorStatus("failed to update reputation", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	b, err := json.Marshal(
+		models.DataResponse{
+			Status:  http.StatusOK,
+			Message: "success",
+			Data:    map[string]any{"result": change},
+		},
+	)
+
+	if err != nil {
+		config.ErrorStatus("failed to marshal response", http.StatusInternalServerError, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(b)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/BugBridge/bugbridge-api/config"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/mux"
 )
 
 type ctxKey string
@@ -79,6 +80,40 @@ func Middleware(config config.Config, next http.Handler) http.Handler {
 	})
 }
 
+// UserIDFromContext returns the ID of the user authenticated by Middleware
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey).(string)
+	return userID, ok && userID != ""
+}
+
+// Deadline cancels the request context once the route's timeout has passed so
+// database calls made with it are abandoned too. Routes are matched on their
+// path template, anything not listed in RouteTimeouts gets RequestTimeout
+func Deadline(config config.Config) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := config.RequestTimeout
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					if d, ok := config.RouteTimeouts[template]; ok {
+						timeout = d
+					}
+				}
+			}
+
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 func MuxCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -1,10 +1,15 @@
 package config
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -14,13 +19,49 @@ import (
 
 // Config holds the project config values
 type Config struct {
+	Driver       string
 	URL          string
 	DatabaseName string
 	BaseURL      string
 	Port         string
 	Secret       string
+
+	RequestTimeout time.Duration            // Deadline applied to every request
+	RouteTimeouts  map[string]time.Duration // Per route deadlines keyed by path template, overriding RequestTimeout
+
+	BlobDriver        string // Where attachment contents are kept, local or gridfs
+	BlobPath          string // Directory the local blob store writes to
+	MaxAttachmentSize int64  // Largest attachment in bytes that can be uploaded
+	ProjectQuota      int64  // Bytes of attachments each project can store
+
+	SLACheckInterval        time.Duration // How often reports are checked for missed SLA deadlines
+	DisclosureCheckInterval time.Duration // How often reports due to be disclosed are made public
+
+	InvitationTTL time.Duration // How long project invitations can be accepted for
 }
 
+// defaultRequestTimeout is used when REQUEST_TIMEOUT is missing or invalid
+const defaultRequestTimeout = 10 * time.Second
+
+// defaultSLACheckInterval is used when SLA_CHECK_INTERVAL is missing or invalid
+const defaultSLACheckInterval = time.Minute
+
+// defaultDisclosureCheckInterval is used when DISCLOSURE_CHECK_INTERVAL is missing or invalid
+const defaultDisclosureCheckInterval = time.Minute
+
+// defaultInvitationTTL is used when INVITATION_TTL is missing or invalid
+const defaultInvitationTTL = 7 * 24 * time.Hour
+
+// attachment limits used when ATTACHMENT_MAX_SIZE or ATTACHMENT_PROJECT_QUOTA are missing or invalid
+const (
+	defaultMaxAttachmentSize = 10 << 20
+	defaultProjectQuota      = 500 << 20
+)
+
+// StatusClientClosedRequest is the non-standard status used when the client
+// went away before we could respond
+const StatusClientClosedRequest = 499
+
 // New sets up all config related services
 func New() *Config {
 
@@ -37,11 +78,25 @@ func New() *Config {
 	_ = zap.ReplaceGlobals(logger)
 
 	return &Config{
+		Driver:       os.Getenv("DB_DRIVER"),
 		URL:          os.Getenv("DB_URI"),
 		DatabaseName: os.Getenv("DB_NAME"),
 		BaseURL:      os.Getenv("BASE_URL"),
 		Port:         os.Getenv("PORT"),
 		Secret: 	  os.Getenv("SECRET"),
+
+		RequestTimeout: parseDuration(os.Getenv("REQUEST_TIMEOUT"), defaultRequestTimeout),
+		RouteTimeouts:  parseRouteTimeouts(os.Getenv("ROUTE_TIMEOUTS")),
+
+		BlobDriver:        os.Getenv("BLOB_DRIVER"),
+		BlobPath:          os.Getenv("BLOB_PATH"),
+		MaxAttachmentSize: parseSize(os.Getenv("ATTACHMENT_MAX_SIZE"), defaultMaxAttachmentSize),
+		ProjectQuota:      parseSize(os.Getenv("ATTACHMENT_PROJECT_QUOTA"), defaultProjectQuota),
+
+		SLACheckInterval:        parseDuration(os.Getenv("SLA_CHECK_INTERVAL"), defaultSLACheckInterval),
+		DisclosureCheckInterval: parseDuration(os.Getenv("DISCLOSURE_CHECK_INTERVAL"), defaultDisclosureCheckInterval),
+
+		InvitationTTL: parseDuration(os.Getenv("INVITATION_TTL"), defaultInvitationTTL),
 	}
 }
 
@@ -53,9 +108,24 @@ func ErrorStatus(
 	w http.ResponseWriter,
 	err error,
 ) {
+	// a deadline or a client going away trumps whatever status the caller expected
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		httpStatusCode = http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled):
+		httpStatusCode = StatusClientClosedRequest
+	}
+
 	zap.S().With(err).Error(message)
+
+	// not every caller has an underlying error, e.g. a lookup that matched nothing
+	errMessage := ""
+	if err != nil {
+		errMessage = err.Error()
+	}
+
 	w.WriteHeader(httpStatusCode)
-	b, _ := json.Marshal(models.ErrorMessageResponse{Response: models.MessageError{Message: message, Error: err.Error()}})
+	b, _ := json.Marshal(models.ErrorMessageResponse{Response: models.MessageError{Message: message, Error: errMessage}})
 	w.Write(b)
 }
 
@@ -72,3 +142,52 @@ func setLogger(env string) (*zap.Logger, error) {
 		return zap.NewExample(), fmt.Errorf("cannon find ENV car so defaulting to debug logging")
 	}
 }
+
+// parseDuration parses a duration such as "10s", falling back when it is missing or invalid
+func parseDuration(value string, fallback time.Duration) time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || d <= 0 {
+		return fallback
+	}
+	return d
+}
+
+// parseSize parses a size in bytes with an optional KB, MB or GB suffix such
+// as "10MB", falling back when it is missing or invalid
+func parseSize(value string, fallback int64) int64 {
+	value = strings.ToUpper(strings.TrimSpace(value))
+
+	unit := int64(1)
+	for suffix, size := range map[string]int64{"KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30} {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			value, unit = strings.TrimSpace(number), size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return fallback
+	}
+	return n * unit
+}
+
+// parseRouteTimeouts parses a comma separated list of path=duration pairs,
+// e.g. "/api/report/search=30s,/api/user/login=5s"
+func parseRouteTimeouts(value string) map[string]time.Duration {
+	timeouts := map[string]time.Duration{}
+	for _, pair := range strings.Split(value, ",") {
+		route, duration, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+
+		d := parseDuration(duration, 0)
+		if d == 0 {
+			zap.S().Warnw("ignoring invalid route timeout", "route", route, "timeout", duration)
+			continue
+		}
+		timeouts[strings.TrimSpace(route)] = d
+	}
+	return timeouts
+}
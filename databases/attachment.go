@@ -0,0 +1,15 @@
+package databases
+
+import (
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+const attachmentDBO = "attachments"
+
+type AttachmentDatabase interface {
+	Repository[models.Attachment]
+}
+
+func NewAttachmentDatabase(db DatabaseHelper) AttachmentDatabase {
+	return NewRepository[models.Attachment](db, attachmentDBO)
+}
@@ -0,0 +1,186 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+
+	"github.com/BugBridge/bugbridge-api/config"
+)
+
+// defaultBlobPath is where the local blob store writes when BLOB_PATH is not set
+const defaultBlobPath = "attachments"
+
+// BlobStore keeps the contents of attachments, keys are chosen by the caller
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStore returns the blob store selected by conf.BlobDriver, the local
+// filesystem is used when no driver is set. GridFS needs the mongo backend
+func NewBlobStore(conf *config.Config, db DatabaseHelper) (BlobStore, error) {
+	switch conf.BlobDriver {
+	case "", "local":
+		root := conf.BlobPath
+		if root == "" {
+			root = defaultBlobPath
+		}
+		if err := os.MkdirAll(root, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create blob directory: %w", err)
+		}
+		return &localBlobStore{root: root}, nil
+	case "gridfs":
+		md, ok := db.(*mongoDatabase)
+		if !ok {
+			return nil, errors.New("the gridfs blob store needs the mongo database driver")
+		}
+		return &gridFSBlobStore{db: md}, nil
+	default:
+		return nil, fmt.Errorf("unknown blob driver %q", conf.BlobDriver)
+	}
+}
+
+type localBlobStore struct {
+	root string
+}
+
+// path returns where a key is stored, keys are spread over directories named
+// after their first two characters so no directory grows too large
+func (ls *localBlobStore) path(key string) (string, error) {
+	if len(key) < 3 || filepath.Base(key) != key || key == ".." {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(ls.root, key[:2], key), nil
+}
+
+func (ls *localBlobStore) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	// write to a temporary file first so readers never see half a blob
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, &contextReader{ctx: ctx, r: r}); err != nil {
+		tmp.Close()
+		return contextError(err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (ls *localBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := ls.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (ls *localBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+type gridFSBlobStore struct {
+	db *mongoDatabase
+}
+
+// bucket opens the default fs bucket with the context deadline applied, the
+// driver's GridFS API takes deadlines instead of contexts for streaming
+func (gs *gridFSBlobStore) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(gs.db.db)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := bucket.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		if err := bucket.SetWriteDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+	return bucket, nil
+}
+
+func (gs *gridFSBlobStore) Put(ctx context.Context, key string, r io.Reader) error {
+	bucket, err := gs.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	return mongoError(bucket.UploadFromStreamWithID(key, key, &contextReader{ctx: ctx, r: r}))
+}
+
+func (gs *gridFSBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	bucket, err := gs.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := bucket.OpenDownloadStream(key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	return stream, nil
+}
+
+func (gs *gridFSBlobStore) Delete(ctx context.Context, key string) error {
+	bucket, err := gs.bucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = bucket.DeleteContext(ctx, key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil
+	}
+	return mongoError(err)
+}
+
+// contextReader stops reading once its context is done so copies of large
+// blobs respect request deadlines
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
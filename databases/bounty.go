@@ -0,0 +1,33 @@
+package databases
+
+import (
+	"context"
+
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+const (
+	bountyDBO = "bounties"
+	ledgerDBO = "ledger"
+)
+
+type BountyDatabase interface {
+	Repository[models.Bounty]
+}
+
+func NewBountyDatabase(db DatabaseHelper) BountyDatabase {
+	return NewRepository[models.Bounty](db, bountyDBO)
+}
+
+// LedgerDatabase can only add and read entries, the ledger is never changed
+type LedgerDatabase interface {
+	FindOne(ctx context.Context, filter any, opts ...FindOptions) (*models.LedgerEntry, error)
+	Find(ctx context.Context, filter any, opts ...FindOptions) ([]models.LedgerEntry, error)
+	FindPage(ctx context.Context, filter any, page Page, opts ...FindOptions) (*PageResult[models.LedgerEntry], error)
+	Count(ctx context.Context, filter any) (int64, error)
+	InsertOne(ctx context.Context, document *models.LedgerEntry) (*InsertOneResult, error)
+}
+
+func NewLedgerDatabase(db DatabaseHelper) LedgerDatabase {
+	return NewRepository[models.LedgerEntry](db, ledgerDBO)
+}
@@ -2,23 +2,42 @@ package databases
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/BugBridge/bugbridge-api/config"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrNotFound is returned when a query matches no documents
+var ErrNotFound = errors.New("document not found")
+
+// ErrDuplicateKey is returned when a write violates a unique index
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// ErrTimeout is returned when an operation runs past the context deadline
+var ErrTimeout = fmt.Errorf("database operation timed out: %w", context.DeadlineExceeded)
+
+// ErrCanceled is returned when the context is canceled before an operation
+// finishes, usually because the client went away
+var ErrCanceled = fmt.Errorf("database operation canceled: %w", context.Canceled)
+
 type DatabaseHelper interface {
 	Collection(name string) CollectionHelper
 	Client() ClientHelper
+	EnsureIndexes(ctx context.Context) error
 }
 
 type CollectionHelper interface {
-	FindOne(context.Context, any) SingleResultHelper
-	Find(context.Context, any) CursorHelper
-	InsertOne(context.Context, any) (mongoInsertOneResult, error)
-	UpdateOne(context.Context, any, any) (mongoUpdateResult, error)
-	DeleteOne(context.Context, any) (mongoDeleteOneResult, error)
+	FindOne(context.Context, any, FindOptions) SingleResultHelper
+	Find(context.Context, any, FindOptions) CursorHelper
+	CountDocuments(context.Context, any) (int64, error)
+	InsertOne(context.Context, any) (InsertOneResult, error)
+	UpdateOne(context.Context, any, any) (UpdateResult, error)
+	UpdateMany(context.Context, any, any) (UpdateResult, error)
+	DeleteOne(context.Context, any) (DeleteResult, error)
+	DeleteMany(context.Context, any) (DeleteResult, error)
 }
 
 type SingleResultHelper interface {
@@ -32,7 +51,6 @@ type CursorHelper interface {
 type ClientHelper interface {
 	Database(string) DatabaseHelper
 	Connect() error
-	StartSession() (mongo.Session, error)
 }
 
 type mongoClient struct {
@@ -52,29 +70,26 @@ type mongoSingleResult struct {
 }
 
 type mongoCursor struct {
-	cr *mongo.Cursor
-}
-
-type mongoInsertOneResult struct {
-	ir *mongo.InsertOneResult
-}
-
-type mongoUpdateResult struct {
-	Ur *mongo.UpdateResult
-}
-
-type mongoDeleteOneResult struct {
-	Dr *mongo.DeleteResult
-}
-
-type mongoSession struct {
-	mongo.Session
+	ctx context.Context
+	cr  *mongo.Cursor
+	err error
 }
 
+// NewClient returns a client for the storage backend selected by conf.Driver,
+// MongoDB is used when no driver is set
 func NewClient(conf *config.Config) (ClientHelper, error) {
-	c, err := mongo.NewClient(options.Client().ApplyURI(conf.URL)) // This is deprecated, lets see if we can find a better option
-
-	return &mongoClient{cl: c}, err
+	switch conf.Driver {
+	case "", "mongo", "mongodb":
+		c, err := mongo.NewClient(options.Client().ApplyURI(conf.URL)) // This is deprecated, lets see if we can find a better option
+
+		return &mongoClient{cl: c}, err
+	case "postgres":
+		return newSQLClient(postgresDialect{}, conf.URL)
+	case "sqlite":
+		return newSQLClient(sqliteDialect{}, sqliteDSN(conf.URL))
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", conf.Driver)
+	}
 }
 
 func NewDatabase(conf *config.Config, client ClientHelper) DatabaseHelper {
@@ -86,11 +101,6 @@ func (mc *mongoClient) Database(dbName string) DatabaseHelper {
 	return &mongoDatabase{db: db}
 }
 
-func (mc *mongoClient) StartSession() (mongo.Session, error) {
-	session, err := mc.cl.StartSession()
-	return &mongoSession{session}, err
-}
-
 func (mc *mongoClient) Connect() error {
 	return mc.cl.Connect(context.TODO()) // This is also deprecated, lets see if we can fix
 }
@@ -105,48 +115,147 @@ func (md *mongoDatabase) Client() ClientHelper {
 	return &mongoClient{cl: client}
 }
 
-func (mc *mongoCollection) FindOne(ctx context.Context, filter any) SingleResultHelper {
-	singleResult := mc.coll.FindOne(ctx, filter)
+// EnsureIndexes creates every index listed in indexes, existing ones are left alone
+func (md *mongoDatabase) EnsureIndexes(ctx context.Context) error {
+	for _, index := range indexes {
+		model := mongo.IndexModel{Keys: index.keys, Options: options.Index().SetUnique(index.unique)}
+		if _, err := md.db.Collection(index.collection).Indexes().CreateOne(ctx, model); err != nil {
+			return fmt.Errorf("failed to create index on %s: %w", index.collection, err)
+		}
+	}
+	return nil
+}
+
+func (mc *mongoCollection) FindOne(ctx context.Context, filter any, opts FindOptions) SingleResultHelper {
+	findOneOptions := options.FindOne()
+	if opts.Projection != nil {
+		findOneOptions.SetProjection(opts.Projection)
+	}
+	if opts.Sort != nil {
+		findOneOptions.SetSort(opts.Sort)
+	}
+	if opts.Skip > 0 {
+		findOneOptions.SetSkip(opts.Skip)
+	}
+
+	singleResult := mc.coll.FindOne(ctx, filter, findOneOptions)
 	return &mongoSingleResult{sr: singleResult}
 }
 
-func (mc *mongoCollection) Find(ctx context.Context, filter any) CursorHelper {
-	cursor, _ := mc.coll.Find(ctx, filter)
-	return &mongoCursor{cr: cursor}
+func (mc *mongoCollection) Find(ctx context.Context, filter any, opts FindOptions) CursorHelper {
+	findOptions := options.Find()
+	if opts.Projection != nil {
+		findOptions.SetProjection(opts.Projection)
+	}
+	if opts.Sort != nil {
+		findOptions.SetSort(opts.Sort)
+	}
+	if opts.Skip > 0 {
+		findOptions.SetSkip(opts.Skip)
+	}
+	if opts.Limit > 0 {
+		findOptions.SetLimit(opts.Limit)
+	}
+
+	cursor, err := mc.coll.Find(ctx, filter, findOptions)
+	return &mongoCursor{ctx: ctx, cr: cursor, err: err}
+}
+
+func (mc *mongoCollection) CountDocuments(ctx context.Context, filter any) (int64, error) {
+	count, err := mc.coll.CountDocuments(ctx, filter)
+	return count, mongoError(err)
 }
 
-func (mc *mongoCollection) InsertOne(ctx context.Context, document any) (mongoInsertOneResult, error) {
+func (mc *mongoCollection) InsertOne(ctx context.Context, document any) (InsertOneResult, error) {
 	insertOneResult, err := mc.coll.InsertOne(ctx, document)
 	if err != nil {
-		return mongoInsertOneResult{}, err
+		return InsertOneResult{}, mongoError(err)
 	}
-	return mongoInsertOneResult{ir: insertOneResult}, nil
+	return InsertOneResult{InsertedID: insertOneResult.InsertedID}, nil
 }
 
-func (mc *mongoCollection) UpdateOne(ctx context.Context, filter, update any) (mongoUpdateResult, error) {
+func (mc *mongoCollection) UpdateOne(ctx context.Context, filter, update any) (UpdateResult, error) {
 	updateOneResult, err := mc.coll.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return mongoUpdateResult{}, err
+		return UpdateResult{}, mongoError(err)
 	}
-	return mongoUpdateResult{Ur: updateOneResult}, nil
+	return newUpdateResult(updateOneResult), nil
 }
 
-func (mc *mongoCollection) DeleteOne(ctx context.Context, filter any) (mongoDeleteOneResult, error) {
+func (mc *mongoCollection) UpdateMany(ctx context.Context, filter, update any) (UpdateResult, error) {
+	updateManyResult, err := mc.coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return UpdateResult{}, mongoError(err)
+	}
+	return newUpdateResult(updateManyResult), nil
+}
+
+func (mc *mongoCollection) DeleteOne(ctx context.Context, filter any) (DeleteResult, error) {
 	deleteOneResult, err := mc.coll.DeleteOne(ctx, filter)
 	if err != nil {
-		return mongoDeleteOneResult{}, err
+		return DeleteResult{}, mongoError(err)
+	}
+	return DeleteResult{DeletedCount: deleteOneResult.DeletedCount}, nil
+}
+
+func (mc *mongoCollection) DeleteMany(ctx context.Context, filter any) (DeleteResult, error) {
+	deleteManyResult, err := mc.coll.DeleteMany(ctx, filter)
+	if err != nil {
+		return DeleteResult{}, mongoError(err)
 	}
-	return mongoDeleteOneResult{Dr: deleteOneResult}, nil
+	return DeleteResult{DeletedCount: deleteManyResult.DeletedCount}, nil
 }
 
 func (sr *mongoSingleResult) Decode(v any) error {
-	return sr.sr.Decode(v)
+	return mongoError(sr.sr.Decode(v))
 }
 
 func (cr *mongoCursor) Decode(v any) error {
-	return cr.All(context.Background(), v)
+	if cr.err != nil {
+		return mongoError(cr.err)
+	}
+	return cr.All(cr.ctx, v)
 }
 
 func (cr *mongoCursor) All(ctx context.Context, results any) error {
-	return cr.cr.All(ctx, results)
+	return mongoError(cr.cr.All(ctx, results))
+}
+
+// newUpdateResult converts the driver's update result into our own
+func newUpdateResult(ur *mongo.UpdateResult) UpdateResult {
+	return UpdateResult{
+		MatchedCount:  ur.MatchedCount,
+		ModifiedCount: ur.ModifiedCount,
+		UpsertedID:    ur.UpsertedID,
+	}
+}
+
+// mongoError maps driver errors onto the package level errors so callers
+// never have to import the mongo driver to check them
+func mongoError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return errors.Join(ErrDuplicateKey, err)
+	case mongo.IsTimeout(err):
+		return errors.Join(ErrTimeout, err)
+	default:
+		return contextError(err)
+	}
+}
+
+// contextError maps context deadlines and cancellation onto ErrTimeout and
+// ErrCanceled so handlers can tell them apart from real failures
+func contextError(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return errors.Join(ErrTimeout, err)
+	case errors.Is(err, context.Canceled):
+		return errors.Join(ErrCanceled, err)
+	default:
+		return err
+	}
 }
@@ -0,0 +1,441 @@
+package databases
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsonrw"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The SQL backends store every document as relaxed extended JSON and evaluate
+// filters, updates, sorting and projection in Go. These helpers give them the
+// subset of MongoDB query semantics that the handlers rely on.
+
+// toDocument normalizes any value the mongo driver would accept (a model
+// struct, bson.M or bson.D) into a bson.M by round tripping it through BSON
+func toDocument(v any) (bson.M, error) {
+	if v == nil {
+		return bson.M{}, nil
+	}
+
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec, err := bson.NewDecoder(bsonrw.NewBSONDocumentReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	dec.DefaultDocumentM()
+
+	doc := bson.M{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// encodeDocument serializes a document for storage
+func encodeDocument(doc bson.M) ([]byte, error) {
+	return bson.MarshalExtJSON(doc, false, false)
+}
+
+// decodeDocument parses a stored document
+func decodeDocument(data []byte) (bson.M, error) {
+	vr, err := bsonrw.NewExtJSONValueReader(bytes.NewReader(data), false)
+	if err != nil {
+		return nil, err
+	}
+
+	dec, err := bson.NewDecoder(vr)
+	if err != nil {
+		return nil, err
+	}
+	dec.DefaultDocumentM()
+
+	doc := bson.M{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// decodeInto decodes a document into v the same way the mongo driver would
+func decodeInto(doc bson.M, v any) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, v)
+}
+
+// decodeAll decodes documents into v, which must be a pointer to a slice
+func decodeAll(docs []bson.M, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("results argument must be a pointer to a slice, got %T", v)
+	}
+
+	slice := rv.Elem()
+	elemType := slice.Type().Elem()
+	out := reflect.MakeSlice(slice.Type(), 0, len(docs))
+	for _, doc := range docs {
+		elem := reflect.New(elemType)
+		if err := decodeInto(doc, elem.Interface()); err != nil {
+			return err
+		}
+		out = reflect.Append(out, elem.Elem())
+	}
+
+	slice.Set(out)
+	return nil
+}
+
+// documentID returns the portable string form of an _id value. ObjectIDs are
+// stored as their hex string so the SQL backends can use a plain text key
+func documentID(v any) (string, error) {
+	switch id := v.(type) {
+	case primitive.ObjectID:
+		return id.Hex(), nil
+	case string:
+		return id, nil
+	default:
+		return "", fmt.Errorf("unsupported _id type %T", v)
+	}
+}
+
+// copyDocument returns a deep copy of doc
+func copyDocument(doc bson.M) bson.M {
+	return copyValue(doc).(bson.M)
+}
+
+func copyValue(v any) any {
+	switch t := v.(type) {
+	case bson.M:
+		out := make(bson.M, len(t))
+		for k, child := range t {
+			out[k] = copyValue(child)
+		}
+		return out
+	case primitive.A:
+		out := make(primitive.A, len(t))
+		for i, child := range t {
+			out[i] = copyValue(child)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// lookup returns every value stored at a dotted path. Arrays met along the way
+// are descended into so "links.reportId" finds the field in every element
+func lookup(v any, path []string) []any {
+	if len(path) == 0 {
+		return []any{v}
+	}
+
+	switch t := v.(type) {
+	case bson.M:
+		child, ok := t[path[0]]
+		if !ok {
+			return nil
+		}
+		return lookup(child, path[1:])
+	case primitive.A:
+		if i, err := strconv.Atoi(path[0]); err == nil {
+			if i < 0 || i >= len(t) {
+				return nil
+			}
+			return lookup(t[i], path[1:])
+		}
+		var out []any
+		for _, elem := range t {
+			out = append(out, lookup(elem, path)...)
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// setPath sets the value at a dotted path, creating intermediate documents
+func setPath(doc bson.M, path string, value any) error {
+	parts := strings.Split(path, ".")
+	current := doc
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part]
+		if !ok || next == nil {
+			child := bson.M{}
+			current[part] = child
+			current = child
+			continue
+		}
+
+		child, ok := next.(bson.M)
+		if !ok {
+			return fmt.Errorf("cannot create field %q in element of type %T", path, next)
+		}
+		current = child
+	}
+
+	current[parts[len(parts)-1]] = value
+	return nil
+}
+
+// getPath returns the value at a dotted path without descending into arrays
+func getPath(doc bson.M, path string) (any, bool) {
+	parts := strings.Split(path, ".")
+	current := doc
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := current[part].(bson.M)
+		if !ok {
+			return nil, false
+		}
+		current = child
+	}
+
+	v, ok := current[parts[len(parts)-1]]
+	return v, ok
+}
+
+// unsetPath removes the value at a dotted path if present
+func unsetPath(doc bson.M, path string) {
+	parts := strings.Split(path, ".")
+	current := doc
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := current[part].(bson.M)
+		if !ok {
+			return
+		}
+		current = child
+	}
+
+	delete(current, parts[len(parts)-1])
+}
+
+// typeRank orders values of different types the way MongoDB does when sorting
+func typeRank(v any) int {
+	switch v.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return 1
+	case int, int32, int64, float64, primitive.Decimal128:
+		return 2
+	case string, primitive.Symbol:
+		return 3
+	case bson.M, bson.D:
+		return 4
+	case primitive.A:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime, time.Time:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	default:
+		return 12
+	}
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+func toDateTime(v any) primitive.DateTime {
+	if t, ok := v.(time.Time); ok {
+		return primitive.NewDateTimeFromTime(t)
+	}
+	return v.(primitive.DateTime)
+}
+
+// compareValues orders two values, values of different types are ordered by
+// their type rank
+func compareValues(a, b any) int {
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		return ra - rb
+	}
+
+	switch ra {
+	case 2:
+		fa, _ := toFloat(a)
+		fb, _ := toFloat(b)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	case 3:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	case 4:
+		return compareDocuments(a.(bson.M), b.(bson.M))
+	case 5:
+		return compareArrays(a.(primitive.A), b.(primitive.A))
+	case 7:
+		return strings.Compare(a.(primitive.ObjectID).Hex(), b.(primitive.ObjectID).Hex())
+	case 8:
+		ba, bb := a.(bool), b.(bool)
+		switch {
+		case ba == bb:
+			return 0
+		case !ba:
+			return -1
+		}
+		return 1
+	case 9:
+		da, db := toDateTime(a), toDateTime(b)
+		switch {
+		case da < db:
+			return -1
+		case da > db:
+			return 1
+		}
+		return 0
+	case 1:
+		return 0
+	default:
+		if reflect.DeepEqual(a, b) {
+			return 0
+		}
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	}
+}
+
+func compareArrays(a, b primitive.A) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := compareValues(a[i], b[i]); c != 0 {
+			return c
+		}
+	}
+	return len(a) - len(b)
+}
+
+func compareDocuments(a, b bson.M) int {
+	keys := func(m bson.M) []string {
+		out := make([]string, 0, len(m))
+		for k := range m {
+			out = append(out, k)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	ka, kb := keys(a), keys(b)
+	for i := 0; i < len(ka) && i < len(kb); i++ {
+		if c := strings.Compare(ka[i], kb[i]); c != 0 {
+			return c
+		}
+		if c := compareValues(a[ka[i]], b[kb[i]]); c != 0 {
+			return c
+		}
+	}
+	return len(ka) - len(kb)
+}
+
+func valuesEqual(a, b any) bool {
+	return typeRank(a) == typeRank(b) && compareValues(a, b) == 0
+}
+
+// sortDocuments orders docs in place by the given sort specification
+func sortDocuments(docs []bson.M, spec bson.D) {
+	if len(spec) == 0 {
+		return
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, field := range spec {
+			direction := 1
+			if n, ok := toFloat(field.Value); ok && n < 0 {
+				direction = -1
+			}
+
+			c := compareValues(sortKey(docs[i], field.Key), sortKey(docs[j], field.Key))
+			if c != 0 {
+				return c*direction < 0
+			}
+		}
+		return false
+	})
+}
+
+func sortKey(doc bson.M, path string) any {
+	values := lookup(doc, strings.Split(path, "."))
+	if len(values) == 0 {
+		return nil
+	}
+	return values[0]
+}
+
+// project applies an inclusion or exclusion projection to doc
+func project(doc bson.M, projection bson.M) bson.M {
+	if len(projection) == 0 {
+		return doc
+	}
+
+	truthy := func(v any) bool {
+		if b, ok := v.(bool); ok {
+			return b
+		}
+		n, ok := toFloat(v)
+		return !ok || n != 0
+	}
+
+	include := false
+	for field, v := range projection {
+		if field != "_id" && truthy(v) {
+			include = true
+		}
+	}
+
+	if !include {
+		out := copyDocument(doc)
+		for field := range projection {
+			unsetPath(out, field)
+		}
+		return out
+	}
+
+	out := bson.M{}
+	if v, ok := projection["_id"]; !ok || truthy(v) {
+		if id, ok := doc["_id"]; ok {
+			out["_id"] = id
+		}
+	}
+	for field, v := range projection {
+		if field == "_id" || !truthy(v) {
+			continue
+		}
+		if value, ok := getPath(doc, field); ok {
+			_ = setPath(out, field, copyValue(value))
+		}
+	}
+	return out
+}
@@ -0,0 +1,15 @@
+package databases
+
+import (
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+const eventDBO = "events"
+
+type EventDatabase interface {
+	Repository[models.Event]
+}
+
+func NewEventDatabase(db DatabaseHelper) EventDatabase {
+	return NewRepository[models.Event](db, eventDBO)
+}
@@ -0,0 +1,40 @@
+package databases
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// indexSpec describes an index the application relies on. The MongoDB
+// backend creates these on startup, the SQL backends get them from migrations
+type indexSpec struct {
+	collection string
+	keys       bson.D
+	unique     bool
+}
+
+var indexes = []indexSpec{
+	{collection: reportDBO, keys: bson.D{{Key: "title", Value: "text"}, {Key: "des", Value: "text"}}},
+	{collection: membershipDBO, keys: bson.D{{Key: "userId", Value: 1}, {Key: "projectId", Value: 1}}, unique: true},
+	{collection: subscriptionDBO, keys: bson.D{{Key: "userId", Value: 1}, {Key: "targetType", Value: 1}, {Key: "targetId", Value: 1}}, unique: true},
+	{collection: voteDBO, keys: bson.D{{Key: "userId", Value: 1}, {Key: "reportId", Value: 1}}, unique: true},
+	{collection: bountyDBO, keys: bson.D{{Key: "reportId", Value: 1}}, unique: true},
+	{collection: reputationDBO, keys: bson.D{{Key: "reportId", Value: 1}, {Key: "kind", Value: 1}}, unique: true},
+	{collection: invitationDBO, keys: bson.D{{Key: "tokenHash", Value: 1}}, unique: true},
+	{collection: templateDBO, keys: bson.D{{Key: "projectId", Value: 1}, {Key: "version", Value: 1}}, unique: true},
+}
+
+// textFields returns the fields covered by the text index of a collection
+func textFields(collection string) []string {
+	var fields []string
+	for _, index := range indexes {
+		if index.collection != collection {
+			continue
+		}
+		for _, key := range index.keys {
+			if key.Value == "text" {
+				fields = append(fields, key.Key)
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,15 @@
+package databases
+
+import (
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+const invitationDBO = "invitations"
+
+type InvitationDatabase interface {
+	Repository[models.Invitation]
+}
+
+func NewInvitationDatabase(db DatabaseHelper) InvitationDatabase {
+	return NewRepository[models.Invitation](db, invitationDBO)
+}
@@ -0,0 +1,266 @@
+package databases
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// matchDocument reports whether doc satisfies a MongoDB style filter
+func matchDocument(doc bson.M, filter bson.M) (bool, error) {
+	for key, cond := range filter {
+		var (
+			ok  bool
+			err error
+		)
+
+		switch key {
+		case "$and", "$or", "$nor":
+			ok, err = matchLogical(doc, key, cond)
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false, fmt.Errorf("unsupported query operator %s", key)
+			}
+			ok, err = matchField(lookup(doc, strings.Split(key, ".")), cond)
+		}
+
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func matchLogical(doc bson.M, op string, cond any) (bool, error) {
+	clauses, ok := cond.(primitive.A)
+	if !ok || len(clauses) == 0 {
+		return false, fmt.Errorf("%s must be a non-empty array", op)
+	}
+
+	for _, clause := range clauses {
+		sub, ok := clause.(bson.M)
+		if !ok {
+			return false, fmt.Errorf("%s entries must be documents", op)
+		}
+
+		matched, err := matchDocument(doc, sub)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case op == "$and" && !matched:
+			return false, nil
+		case op == "$or" && matched:
+			return true, nil
+		case op == "$nor" && matched:
+			return false, nil
+		}
+	}
+	return op != "$or", nil
+}
+
+// operatorDocument returns cond as a document when every key is an operator
+func operatorDocument(cond any) (bson.M, bool) {
+	ops, ok := cond.(bson.M)
+	if !ok || len(ops) == 0 {
+		return nil, false
+	}
+	for key := range ops {
+		if !strings.HasPrefix(key, "$") {
+			return nil, false
+		}
+	}
+	return ops, true
+}
+
+// matchField checks the values found at a path against a condition which is
+// either a literal to compare for equality or a document of operators
+func matchField(values []any, cond any) (bool, error) {
+	ops, ok := operatorDocument(cond)
+	if !ok {
+		if re, ok := cond.(primitive.Regex); ok {
+			return matchRegex(values, re.Pattern, re.Options)
+		}
+		return matchEqual(values, cond), nil
+	}
+
+	for op, arg := range ops {
+		matched, err := matchOperator(values, op, arg, ops)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// candidates expands array values so an operator can match any element
+func candidates(values []any) []any {
+	out := make([]any, 0, len(values))
+	for _, v := range values {
+		out = append(out, v)
+		if arr, ok := v.(primitive.A); ok {
+			out = append(out, arr...)
+		}
+	}
+	return out
+}
+
+func matchEqual(values []any, want any) bool {
+	if want == nil && len(values) == 0 {
+		return true
+	}
+	for _, v := range candidates(values) {
+		if valuesEqual(v, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchCompare(values []any, want any, accept func(int) bool) bool {
+	for _, v := range candidates(values) {
+		if typeRank(v) == typeRank(want) && accept(compareValues(v, want)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchOperator(values []any, op string, arg any, ops bson.M) (bool, error) {
+	switch op {
+	case "$eq":
+		return matchEqual(values, arg), nil
+	case "$ne":
+		return !matchEqual(values, arg), nil
+	case "$gt":
+		return matchCompare(values, arg, func(c int) bool { return c > 0 }), nil
+	case "$gte":
+		return matchCompare(values, arg, func(c int) bool { return c >= 0 }), nil
+	case "$lt":
+		return matchCompare(values, arg, func(c int) bool { return c < 0 }), nil
+	case "$lte":
+		return matchCompare(values, arg, func(c int) bool { return c <= 0 }), nil
+	case "$in", "$nin":
+		list, ok := arg.(primitive.A)
+		if !ok {
+			return false, fmt.Errorf("%s needs an array", op)
+		}
+		found := false
+		for _, want := range list {
+			if matchEqual(values, want) {
+				found = true
+				break
+			}
+		}
+		return found == (op == "$in"), nil
+	case "$all":
+		list, ok := arg.(primitive.A)
+		if !ok {
+			return false, fmt.Errorf("$all needs an array")
+		}
+		for _, want := range list {
+			if !matchEqual(values, want) {
+				return false, nil
+			}
+		}
+		return len(list) > 0, nil
+	case "$exists":
+		want, ok := arg.(bool)
+		if !ok {
+			n, isNumber := toFloat(arg)
+			want = isNumber && n != 0
+		}
+		return (len(values) > 0) == want, nil
+	case "$size":
+		size, ok := toFloat(arg)
+		if !ok {
+			return false, fmt.Errorf("$size needs a number")
+		}
+		for _, v := range values {
+			if arr, ok := v.(primitive.A); ok && float64(len(arr)) == size {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "$elemMatch":
+		sub, ok := arg.(bson.M)
+		if !ok {
+			return false, fmt.Errorf("$elemMatch needs a document")
+		}
+		return matchElem(values, sub)
+	case "$not":
+		matched, err := matchField(values, arg)
+		return !matched, err
+	case "$regex":
+		options, _ := ops["$options"].(string)
+		switch pattern := arg.(type) {
+		case string:
+			return matchRegex(values, pattern, options)
+		case primitive.Regex:
+			return matchRegex(values, pattern.Pattern, pattern.Options+options)
+		}
+		return false, fmt.Errorf("$regex needs a string")
+	case "$options":
+		// consumed by $regex
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported query operator %s", op)
+	}
+}
+
+func matchElem(values []any, sub bson.M) (bool, error) {
+	_, isOperators := operatorDocument(sub)
+	for _, v := range values {
+		arr, ok := v.(primitive.A)
+		if !ok {
+			continue
+		}
+		for _, elem := range arr {
+			var (
+				matched bool
+				err     error
+			)
+			if doc, ok := elem.(bson.M); ok && !isOperators {
+				matched, err = matchDocument(doc, sub)
+			} else {
+				matched, err = matchField([]any{elem}, sub)
+			}
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func matchRegex(values []any, pattern, options string) (bool, error) {
+	flags := ""
+	for _, o := range options {
+		switch o {
+		case 'i', 'm', 's':
+			flags += string(o)
+		}
+	}
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	for _, v := range candidates(values) {
+		if s, ok := v.(string); ok && re.MatchString(s) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
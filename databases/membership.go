@@ -0,0 +1,15 @@
+package databases
+
+import (
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+const membershipDBO = "memberships"
+
+type MembershipDatabase interface {
+	Repository[models.Membership]
+}
+
+func NewMembershipDatabase(db DatabaseHelper) MembershipDatabase {
+	return NewRepository[models.Membership](db, membershipDBO)
+}
@@ -0,0 +1,22 @@
+-- Every collection is a table of documents keyed by the hex form of their
+-- ObjectID, the document itself is stored as relaxed extended JSON
+
+CREATE TABLE IF NOT EXISTS users (
+    id  TEXT PRIMARY KEY,
+    doc JSONB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS projects (
+    id  TEXT PRIMARY KEY,
+    doc JSONB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS reports (
+    id  TEXT PRIMARY KEY,
+    doc JSONB NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+    id  TEXT PRIMARY KEY,
+    doc JSONB NOT NULL
+);
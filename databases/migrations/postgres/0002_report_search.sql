@@ -0,0 +1,5 @@
+-- Full text search over report titles and descriptions, the expression must
+-- match the one built by postgresTextDocument for the index to be used
+
+CREATE INDEX IF NOT EXISTS reports_search_idx ON reports
+    USING GIN (to_tsvector('simple', coalesce(doc->>'title', '') || ' ' || coalesce(doc->>'des', '')));
@@ -0,0 +1,8 @@
+-- Memberships are the source of truth for who belongs to which project
+
+CREATE TABLE IF NOT EXISTS memberships (
+    id  TEXT PRIMARY KEY,
+    doc JSONB NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS memberships_user_project_idx ON memberships ((doc->>'userId'), (doc->>'projectId'));
@@ -0,0 +1,8 @@
+-- Votes on reports, a user votes on each report at most once
+
+CREATE TABLE IF NOT EXISTS votes (
+    id  TEXT PRIMARY KEY,
+    doc JSONB NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS votes_user_report_idx ON votes ((doc->>'userId'), (doc->>'reportId'));
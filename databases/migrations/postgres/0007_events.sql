@@ -0,0 +1,6 @@
+-- Events fanned out to the watchers of reports and projects
+
+CREATE TABLE IF NOT EXISTS events (
+    id  TEXT PRIMARY KEY,
+    doc JSONB NOT NULL
+);
@@ -0,0 +1,8 @@
+-- Reputation events earned by report authors, a report earns each kind of event once
+
+CREATE TABLE IF NOT EXISTS reputation (
+    id  TEXT PRIMARY KEY,
+    doc JSONB NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS reputation_report_kind_idx ON reputation ((doc->>'reportId'), (doc->>'kind'));
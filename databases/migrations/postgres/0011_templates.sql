@@ -0,0 +1,8 @@
+-- Versions of project templates, each version number is used once per project
+
+CREATE TABLE IF NOT EXISTS templates (
+    id  TEXT PRIMARY KEY,
+    doc JSONB NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS templates_project_version_idx ON templates ((doc->>'projectId'), (doc->>'version'));
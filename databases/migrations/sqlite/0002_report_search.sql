@@ -0,0 +1,22 @@
+-- Full text search over report titles and descriptions. The FTS5 table is
+-- kept in step with the reports table by triggers
+
+CREATE VIRTUAL TABLE IF NOT EXISTS reports_fts USING fts5(id UNINDEXED, title, des);
+
+INSERT INTO reports_fts (id, title, des)
+    SELECT id, json_extract(doc, '$.title'), json_extract(doc, '$.des') FROM reports;
+
+CREATE TRIGGER IF NOT EXISTS reports_fts_insert AFTER INSERT ON reports BEGIN
+    INSERT INTO reports_fts (id, title, des)
+        VALUES (new.id, json_extract(new.doc, '$.title'), json_extract(new.doc, '$.des'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS reports_fts_update AFTER UPDATE ON reports BEGIN
+    DELETE FROM reports_fts WHERE id = old.id;
+    INSERT INTO reports_fts (id, title, des)
+        VALUES (new.id, json_extract(new.doc, '$.title'), json_extract(new.doc, '$.des'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS reports_fts_delete AFTER DELETE ON reports BEGIN
+    DELETE FROM reports_fts WHERE id = old.id;
+END;
@@ -0,0 +1,8 @@
+-- Memberships are the source of truth for who belongs to which project
+
+CREATE TABLE IF NOT EXISTS memberships (
+    id  TEXT PRIMARY KEY,
+    doc TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS memberships_user_project_idx ON memberships (json_extract(doc, '$.userId'), json_extract(doc, '$.projectId'));
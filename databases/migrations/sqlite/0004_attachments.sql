@@ -0,0 +1,6 @@
+-- Attachment metadata, the contents live in the blob store
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id  TEXT PRIMARY KEY,
+    doc TEXT NOT NULL
+);
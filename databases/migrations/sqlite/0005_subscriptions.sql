@@ -0,0 +1,8 @@
+-- Users watching reports and projects, a user watches each target at most once
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id  TEXT PRIMARY KEY,
+    doc TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS subscriptions_user_target_idx ON subscriptions (json_extract(doc, '$.userId'), json_extract(doc, '$.targetType'), json_extract(doc, '$.targetId'));
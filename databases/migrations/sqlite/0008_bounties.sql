@@ -0,0 +1,13 @@
+-- Bounties awarded for reports and the ledger recording them, each report is awarded at most one bounty
+
+CREATE TABLE IF NOT EXISTS bounties (
+    id  TEXT PRIMARY KEY,
+    doc TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS bounties_report_idx ON bounties (json_extract(doc, '$.reportId'));
+
+CREATE TABLE IF NOT EXISTS ledger (
+    id  TEXT PRIMARY KEY,
+    doc TEXT NOT NULL
+);
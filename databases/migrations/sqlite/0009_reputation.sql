@@ -0,0 +1,8 @@
+-- Reputation events earned by report authors, a report earns each kind of event once
+
+CREATE TABLE IF NOT EXISTS reputation (
+    id  TEXT PRIMARY KEY,
+    doc TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS reputation_report_kind_idx ON reputation (json_extract(doc, '$.reportId'), json_extract(doc, '$.kind'));
@@ -0,0 +1,8 @@
+-- Invitations to join projects, each is answered with a token only its hash is kept of
+
+CREATE TABLE IF NOT EXISTS invitations (
+    id  TEXT PRIMARY KEY,
+    doc TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS invitations_token_idx ON invitations (json_extract(doc, '$.tokenHash'));
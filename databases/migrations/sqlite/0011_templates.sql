@@ -0,0 +1,8 @@
+-- Versions of project templates, each version number is used once per project
+
+CREATE TABLE IF NOT EXISTS templates (
+    id  TEXT PRIMARY KEY,
+    doc TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS templates_project_version_idx ON templates (json_extract(doc, '$.projectId'), json_extract(doc, '$.version'));
@@ -0,0 +1,53 @@
+package databases
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// postgresDialect stores collections in PostgreSQL using JSONB documents
+type postgresDialect struct{}
+
+func (postgresDialect) driverName() string {
+	return "postgres"
+}
+
+func (postgresDialect) migrationDir() string {
+	return "postgres"
+}
+
+func (postgresDialect) placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (postgresDialect) lockRows() string {
+	return " FOR UPDATE"
+}
+
+func (postgresDialect) isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func (postgresDialect) textSearch(_ string, fields []string, terms []string, n int) (string, any) {
+	return "to_tsvector('simple', " + postgresTextDocument(fields) + ") @@ to_tsquery('simple', $" + strconv.Itoa(n) + ")", strings.Join(terms, " | ")
+}
+
+// fieldEquals relies on jsonb containment, where an array contains each of its
+// elements and a string contains only itself
+func (postgresDialect) fieldEquals(field string, value string, n int) (string, []any) {
+	return "doc->($" + strconv.Itoa(n) + "::text) @> to_jsonb($" + strconv.Itoa(n+1) + "::text)", []any{field, value}
+}
+
+// postgresTextDocument concatenates the text index fields, it must stay in
+// step with the expression the search index is built on in the migrations
+func postgresTextDocument(fields []string) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = "coalesce(doc->>'" + field + "', '')"
+	}
+	return strings.Join(parts, " || ' ' || ")
+}
@@ -1,69 +1,15 @@
 package databases
 
 import (
-	"context"
-
 	"github.com/BugBridge/bugbridge-api/models"
 )
 
 const reportDBO = "reports"
 
 type ReportDatabase interface {
-	FindOne(ctx context.Context, filter any) (*models.Report, error)
-	Find(ctx context.Context, filter any) ([]models.Report, error)
-	InsertOne(ctx context.Context, document any) (*mongoInsertOneResult, error)
-	UpdateOne(ctx context.Context, filter, document any) (*mongoUpdateResult, error)
-	DeleteOne(ctx context.Context, filter any) (*mongoDeleteOneResult, error)
-}
-
-type reportDatabase struct {
-	db DatabaseHelper
+	Repository[models.Report]
 }
 
 func NewReportDatabase(db DatabaseHelper) ReportDatabase {
-	return &reportDatabase{
-		db: db,
-	}
-}
-
-func (u *reportDatabase) FindOne(ctx context.Context, filter any) (*models.Report, error) {
-	report := &models.Report{}
-	err := u.db.Collection(reportDBO).FindOne(ctx, filter).Decode(&report)
-	if err != nil {
-		return nil, err
-	}
-	return report, nil
-}
-
-func (u *reportDatabase) Find(ctx context.Context, filter any) ([]models.Report, error) {
-	var reports []models.Report
-	err := u.db.Collection(reportDBO).Find(ctx, filter).Decode(&reports)
-	if err != nil {
-		return nil, err
-	}
-	return reports, nil
-}
-
-func (u *reportDatabase) InsertOne(ctx context.Context, document any) (*mongoInsertOneResult, error) {
-	result, err := u.db.Collection(reportDBO).InsertOne(ctx, document)
-	if err != nil {
-		return nil, err
-	}
-	return &result, nil
-}
-
-func (u *reportDatabase) UpdateOne(ctx context.Context, filter, update any) (*mongoUpdateResult, error) {
-	result, err := u.db.Collection(reportDBO).UpdateOne(ctx, filter, update)
-	if err != nil {
-		return nil, err
-	}
-	return &result, nil
-}
-
-func (u *reportDatabase) DeleteOne(ctx context.Context, filter any) (*mongoDeleteOneResult, error) {
-	result, err := u.db.Collection(reportDBO).DeleteOne(ctx, filter)
-	if err != nil {
-		return nil, err
-	}
-	return &result, nil
+	return NewRepository[models.Report](db, reportDBO)
 }
@@ -0,0 +1,248 @@
+package databases
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// InsertOneResult is returned after inserting a single document
+type InsertOneResult struct {
+	InsertedID any `json:"insertedId"` // ID of the inserted document
+}
+
+// UpdateResult is returned after updating one or more documents
+type UpdateResult struct {
+	MatchedCount  int64 `json:"matchedCount"`         // Number of documents matched by the filter
+	ModifiedCount int64 `json:"modifiedCount"`        // Number of documents that were changed
+	UpsertedID    any   `json:"upsertedId,omitempty"` // ID of the document inserted by an upsert
+}
+
+// DeleteResult is returned after deleting one or more documents
+type DeleteResult struct {
+	DeletedCount int64 `json:"deletedCount"` // Number of documents removed
+}
+
+// FindOptions controls ordering, paging and projection of a query
+type FindOptions struct {
+	Sort       bson.D // Fields to sort by, 1 for ascending and -1 for descending
+	Skip       int64  // Number of documents to skip
+	Limit      int64  // Maximum number of documents to return, 0 means no limit
+	Projection bson.M // Fields to include or exclude from the result
+}
+
+// Page is a 1-indexed page of results requested by a client
+type Page struct {
+	Number int64 `json:"page"`
+	Size   int64 `json:"pageSize"`
+}
+
+// PageResult holds a single page of results with the total count of matches
+type PageResult[T any] struct {
+	Items    []T   `json:"items"`
+	Total    int64 `json:"total"`
+	Page     int64 `json:"page"`
+	PageSize int64 `json:"pageSize"`
+}
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Repository is a typed wrapper around a single collection
+type Repository[T any] interface {
+	FindOne(ctx context.Context, filter any, opts ...FindOptions) (*T, error)
+	FindByID(ctx context.Context, id primitive.ObjectID, opts ...FindOptions) (*T, error)
+	Find(ctx context.Context, filter any, opts ...FindOptions) ([]T, error)
+	FindPage(ctx context.Context, filter any, page Page, opts ...FindOptions) (*PageResult[T], error)
+	Count(ctx context.Context, filter any) (int64, error)
+	Exists(ctx context.Context, filter any) (bool, error)
+	InsertOne(ctx context.Context, document *T) (*InsertOneResult, error)
+	UpdateOne(ctx context.Context, filter, update any) (*UpdateResult, error)
+	UpdateByID(ctx context.Context, id primitive.ObjectID, update any) (*UpdateResult, error)
+	UpdateMany(ctx context.Context, filter, update any) (*UpdateResult, error)
+	DeleteOne(ctx context.Context, filter any) (*DeleteResult, error)
+	DeleteByID(ctx context.Context, id primitive.ObjectID) (*DeleteResult, error)
+	DeleteMany(ctx context.Context, filter any) (*DeleteResult, error)
+}
+
+type repository[T any] struct {
+	db         DatabaseHelper
+	collection string
+}
+
+// NewRepository returns a Repository for the named collection
+func NewRepository[T any](db DatabaseHelper, collection string) Repository[T] {
+	return &repository[T]{
+		db:         db,
+		collection: collection,
+	}
+}
+
+func (r *repository[T]) coll() CollectionHelper {
+	return r.db.Collection(r.collection)
+}
+
+func (r *repository[T]) FindOne(ctx context.Context, filter any, opts ...FindOptions) (*T, error) {
+	document := new(T)
+	err := r.coll().FindOne(ctx, filter, mergeFindOptions(opts)).Decode(document)
+	if err != nil {
+		return nil, err
+	}
+	return document, nil
+}
+
+func (r *repository[T]) FindByID(ctx context.Context, id primitive.ObjectID, opts ...FindOptions) (*T, error) {
+	return r.FindOne(ctx, bson.M{"_id": id}, opts...)
+}
+
+func (r *repository[T]) Find(ctx context.Context, filter any, opts ...FindOptions) ([]T, error) {
+	documents := []T{}
+	err := r.coll().Find(ctx, filter, mergeFindOptions(opts)).Decode(&documents)
+	if err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+func (r *repository[T]) FindPage(ctx context.Context, filter any, page Page, opts ...FindOptions) (*PageResult[T], error) {
+	page = page.normalize()
+
+	findOptions := mergeFindOptions(opts)
+	findOptions.Skip = (page.Number - 1) * page.Size
+	findOptions.Limit = page.Size
+
+	items, err := r.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := r.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PageResult[T]{
+		Items:    items,
+		Total:    total,
+		Page:     page.Number,
+		PageSize: page.Size,
+	}, nil
+}
+
+func (r *repository[T]) Count(ctx context.Context, filter any) (int64, error) {
+	return r.coll().CountDocuments(ctx, filter)
+}
+
+func (r *repository[T]) Exists(ctx context.Context, filter any) (bool, error) {
+	var document bson.M
+	err := r.coll().FindOne(ctx, filter, FindOptions{Projection: bson.M{"_id": 1}}).Decode(&document)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *repository[T]) InsertOne(ctx context.Context, document *T) (*InsertOneResult, error) {
+	result, err := r.coll().InsertOne(ctx, document)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *repository[T]) UpdateOne(ctx context.Context, filter, update any) (*UpdateResult, error) {
+	result, err := r.coll().UpdateOne(ctx, filter, update)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *repository[T]) UpdateByID(ctx context.Context, id primitive.ObjectID, update any) (*UpdateResult, error) {
+	return r.UpdateOne(ctx, bson.M{"_id": id}, update)
+}
+
+func (r *repository[T]) UpdateMany(ctx context.Context, filter, update any) (*UpdateResult, error) {
+	result, err := r.coll().UpdateMany(ctx, filter, update)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *repository[T]) DeleteOne(ctx context.Context, filter any) (*DeleteResult, error) {
+	result, err := r.coll().DeleteOne(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *repository[T]) DeleteByID(ctx context.Context, id primitive.ObjectID) (*DeleteResult, error) {
+	return r.DeleteOne(ctx, bson.M{"_id": id})
+}
+
+func (r *repository[T]) DeleteMany(ctx context.Context, filter any) (*DeleteResult, error) {
+	result, err := r.coll().DeleteMany(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// normalize fills in defaults for a missing page number or size and caps the size
+func (p Page) normalize() Page {
+	if p.Number < 1 {
+		p.Number = 1
+	}
+	if p.Size < 1 {
+		p.Size = defaultPageSize
+	}
+	if p.Size > maxPageSize {
+		p.Size = maxPageSize
+	}
+	return p
+}
+
+// Paginate returns a page of items that were worked out in memory rather
+// than read from a collection, using the same defaults as FindPage
+func Paginate[T any](items []T, page Page) *PageResult[T] {
+	page = page.normalize()
+
+	start := min((page.Number-1)*page.Size, int64(len(items)))
+	end := min(start+page.Size, int64(len(items)))
+
+	return &PageResult[T]{
+		Items:    append([]T{}, items[start:end]...),
+		Total:    int64(len(items)),
+		Page:     page.Number,
+		PageSize: page.Size,
+	}
+}
+
+// mergeFindOptions collapses variadic options into one, later values win
+func mergeFindOptions(opts []FindOptions) FindOptions {
+	merged := FindOptions{}
+	for _, opt := range opts {
+		if opt.Sort != nil {
+			merged.Sort = opt.Sort
+		}
+		if opt.Skip > 0 {
+			merged.Skip = opt.Skip
+		}
+		if opt.Limit > 0 {
+			merged.Limit = opt.Limit
+		}
+		if opt.Projection != nil {
+			merged.Projection = opt.Projection
+		}
+	}
+	return merged
+}
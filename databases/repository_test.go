@@ -0,0 +1,328 @@
+package databases
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/BugBridge/bugbridge-api/config"
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+// The same suite runs against every backend. SQLite always runs, MongoDB and
+// PostgreSQL run when TEST_MONGO_URI and TEST_POSTGRES_URI point at a server
+// the suite is allowed to empty
+
+type testBackend struct {
+	name string
+	conf *config.Config
+}
+
+func testBackends(t *testing.T) []testBackend {
+	backends := []testBackend{
+		{name: "sqlite", conf: &config.Config{Driver: "sqlite", URL: filepath.Join(t.TempDir(), "test.db")}},
+	}
+	if uri := os.Getenv("TEST_POSTGRES_URI"); uri != "" {
+		backends = append(backends, testBackend{name: "postgres", conf: &config.Config{Driver: "postgres", URL: uri}})
+	}
+	if uri := os.Getenv("TEST_MONGO_URI"); uri != "" {
+		backends = append(backends, testBackend{name: "mongo", conf: &config.Config{Driver: "mongo", URL: uri, DatabaseName: "bugbridge_test"}})
+	}
+	return backends
+}
+
+// forEachBackend runs fn once per backend with empty reports and votes collections
+func forEachBackend(t *testing.T, fn func(t *testing.T, reports Repository[models.Report], votes Repository[models.Vote])) {
+	for _, backend := range testBackends(t) {
+		t.Run(backend.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			client, err := NewClient(backend.conf)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+			if err := client.Connect(); err != nil {
+				t.Fatalf("failed to connect: %v", err)
+			}
+
+			db := NewDatabase(backend.conf, client)
+			if err := db.EnsureIndexes(ctx); err != nil {
+				t.Fatalf("failed to create indexes: %v", err)
+			}
+
+			reports := NewRepository[models.Report](db, reportDBO)
+			votes := NewRepository[models.Vote](db, voteDBO)
+			for _, empty := range []func() error{
+				func() error { _, err := reports.DeleteMany(ctx, bson.M{}); return err },
+				func() error { _, err := votes.DeleteMany(ctx, bson.M{}); return err },
+			} {
+				if err := empty(); err != nil {
+					t.Fatalf("failed to empty collection: %v", err)
+				}
+			}
+
+			fn(t, reports, votes)
+		})
+	}
+}
+
+// seedReports inserts the reports the query cases run against
+func seedReports(t *testing.T, reports Repository[models.Report]) {
+	t.Helper()
+
+	triagedAt := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	seed := []models.Report{
+		{
+			Title: "Login crash", Des: "the app crashes on login", ProjectID: "p1", AuthorID: "alice", Severity: 3,
+			Labels: []string{"ui", "crash"},
+			Links:  []models.ReportLink{{Type: "blocks", ReportID: "r9"}},
+			Triage: &models.Triage{Level: "high", TriagedBy: "admin", TriagedAt: triagedAt},
+		},
+		{
+			Title: "Slow search", Des: "searching takes seconds", ProjectID: "p1", AuthorID: "bob", Severity: 1,
+			Labels: []string{"perf"},
+			Links:  []models.ReportLink{{Type: "duplicate", ReportID: "r9"}},
+		},
+		{
+			Title: "Upload crash", Des: "uploads fail half way", ProjectID: "p2", AuthorID: "alice", Severity: 2,
+			Labels: []string{},
+			Links:  []models.ReportLink{},
+		},
+	}
+
+	for i := range seed {
+		seed[i].ID = primitive.NewObjectID()
+		if _, err := reports.InsertOne(context.Background(), &seed[i]); err != nil {
+			t.Fatalf("failed to insert %q: %v", seed[i].Title, err)
+		}
+	}
+}
+
+func titles(reports []models.Report) []string {
+	out := []string{}
+	for _, report := range reports {
+		out = append(out, report.Title)
+	}
+	return out
+}
+
+func TestRepositoryFind(t *testing.T) {
+	cases := []struct {
+		name   string
+		filter bson.M
+		want   []string
+	}{
+		{"equality", bson.M{"projectId": "p1"}, []string{"Login crash", "Slow search"}},
+		{"equality on author", bson.M{"author": "alice"}, []string{"Login crash", "Upload crash"}},
+		{"equality matches array elements", bson.M{"labels": "crash"}, []string{"Login crash"}},
+		{"$eq", bson.M{"projectId": bson.M{"$eq": "p2"}}, []string{"Upload crash"}},
+		{"$in", bson.M{"severity": bson.M{"$in": bson.A{1, 2}}}, []string{"Slow search", "Upload crash"}},
+		{"$in on arrays", bson.M{"labels": bson.M{"$in": bson.A{"perf", "ui"}}}, []string{"Login crash", "Slow search"}},
+		{"$nin", bson.M{"projectId": bson.M{"$nin": bson.A{"p1"}}}, []string{"Upload crash"}},
+		{"$ne", bson.M{"projectId": bson.M{"$ne": "p1"}}, []string{"Upload crash"}},
+		{"$ne on arrays", bson.M{"labels": bson.M{"$ne": "crash"}}, []string{"Slow search", "Upload crash"}},
+		{"$exists", bson.M{"triage": bson.M{"$exists": true}}, []string{"Login crash"}},
+		{"$exists false", bson.M{"triage": bson.M{"$exists": false}}, []string{"Slow search", "Upload crash"}},
+		{"$gt and $lte", bson.M{"severity": bson.M{"$gt": 1, "$lte": 2}}, []string{"Upload crash"}},
+		{"$or", bson.M{"$or": bson.A{bson.M{"projectId": "p2"}, bson.M{"severity": 1}}}, []string{"Slow search", "Upload crash"}},
+		{"$and", bson.M{"$and": bson.A{bson.M{"projectId": "p1"}, bson.M{"author": "alice"}}}, []string{"Login crash"}},
+		{"$elemMatch", bson.M{"links": bson.M{"$elemMatch": bson.M{"type": "duplicate", "reportId": "r9"}}}, []string{"Slow search"}},
+		{"dotted path into a document", bson.M{"triage.level": "high"}, []string{"Login crash"}},
+		{"dotted path through an array", bson.M{"links.type": "blocks"}, []string{"Login crash"}},
+		{"$text", bson.M{"$text": bson.M{"$search": "crash"}}, []string{"Login crash", "Upload crash"}},
+		{"$text with any term", bson.M{"$text": bson.M{"$search": "searching uploads"}}, []string{"Slow search", "Upload crash"}},
+		{"$text with another condition", bson.M{"$text": bson.M{"$search": "crash"}, "projectId": "p2"}, []string{"Upload crash"}},
+		{"no match", bson.M{"projectId": "p3"}, []string{}},
+	}
+
+	forEachBackend(t, func(t *testing.T, reports Repository[models.Report], _ Repository[models.Vote]) {
+		seedReports(t, reports)
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				found, err := reports.Find(context.Background(), tc.filter, FindOptions{Sort: bson.D{{Key: "title", Value: 1}}})
+				if err != nil {
+					t.Fatalf("Find failed: %v", err)
+				}
+				if got := titles(found); !slices.Equal(got, tc.want) {
+					t.Errorf("got %v, want %v", got, tc.want)
+				}
+
+				count, err := reports.Count(context.Background(), tc.filter)
+				if err != nil {
+					t.Fatalf("Count failed: %v", err)
+				}
+				if count != int64(len(tc.want)) {
+					t.Errorf("counted %d, want %d", count, len(tc.want))
+				}
+			})
+		}
+	})
+}
+
+func TestRepositorySortAndPage(t *testing.T) {
+	cases := []struct {
+		name  string
+		sort  bson.D
+		page  Page
+		want  []string
+		total int64
+	}{
+		{"descending first page", bson.D{{Key: "severity", Value: -1}}, Page{Number: 1, Size: 2}, []string{"Login crash", "Upload crash"}, 3},
+		{"descending last page", bson.D{{Key: "severity", Value: -1}}, Page{Number: 2, Size: 2}, []string{"Slow search"}, 3},
+		{"past the end", bson.D{{Key: "severity", Value: -1}}, Page{Number: 3, Size: 2}, []string{}, 3},
+		{"two keys", bson.D{{Key: "projectId", Value: -1}, {Key: "title", Value: 1}}, Page{Number: 1, Size: 10}, []string{"Upload crash", "Login crash", "Slow search"}, 3},
+	}
+
+	forEachBackend(t, func(t *testing.T, reports Repository[models.Report], _ Repository[models.Vote]) {
+		seedReports(t, reports)
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				result, err := reports.FindPage(context.Background(), bson.M{}, tc.page, FindOptions{Sort: tc.sort})
+				if err != nil {
+					t.Fatalf("FindPage failed: %v", err)
+				}
+				if got := titles(result.Items); !slices.Equal(got, tc.want) {
+					t.Errorf("got %v, want %v", got, tc.want)
+				}
+				if result.Total != tc.total {
+					t.Errorf("total %d, want %d", result.Total, tc.total)
+				}
+			})
+		}
+	})
+}
+
+func TestRepositoryUpdate(t *testing.T) {
+	cases := []struct {
+		name   string
+		update bson.M
+		check  func(report *models.Report) bool
+	}{
+		{"$set", bson.M{"$set": bson.M{"state": "closed"}}, func(r *models.Report) bool { return r.State == "closed" }},
+		{"$set dotted path", bson.M{"$set": bson.M{"triage.level": "low"}}, func(r *models.Report) bool { return r.Triage.Level == "low" && r.Triage.TriagedBy == "admin" }},
+		{"$inc", bson.M{"$inc": bson.M{"votes": 2}}, func(r *models.Report) bool { return r.Votes == 2 }},
+		{"$inc twice", bson.M{"$inc": bson.M{"votes": -1}}, func(r *models.Report) bool { return r.Votes == 1 }},
+		{"$push", bson.M{"$push": bson.M{"labels": "new"}}, func(r *models.Report) bool { return slices.Equal(r.Labels, []string{"ui", "crash", "new"}) }},
+		{"$pull", bson.M{"$pull": bson.M{"labels": "ui"}}, func(r *models.Report) bool { return slices.Equal(r.Labels, []string{"crash", "new"}) }},
+		{"$pull by condition", bson.M{"$pull": bson.M{"links": bson.M{"reportId": "r9"}}}, func(r *models.Report) bool { return len(r.Links) == 0 }},
+		{"$unset", bson.M{"$unset": bson.M{"triage": ""}}, func(r *models.Report) bool { return r.Triage == nil }},
+	}
+
+	forEachBackend(t, func(t *testing.T, reports Repository[models.Report], _ Repository[models.Vote]) {
+		ctx := context.Background()
+		seedReports(t, reports)
+
+		target, err := reports.FindOne(ctx, bson.M{"title": "Login crash"})
+		if err != nil {
+			t.Fatalf("FindOne failed: %v", err)
+		}
+
+		// the cases run in order against the same report
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				result, err := reports.UpdateByID(ctx, target.ID, tc.update)
+				if err != nil {
+					t.Fatalf("UpdateByID failed: %v", err)
+				}
+				if result.MatchedCount != 1 || result.ModifiedCount != 1 {
+					t.Errorf("matched %d and modified %d, want 1 and 1", result.MatchedCount, result.ModifiedCount)
+				}
+
+				updated, err := reports.FindByID(ctx, target.ID)
+				if err != nil {
+					t.Fatalf("FindByID failed: %v", err)
+				}
+				if !tc.check(updated) {
+					t.Errorf("update not applied, got %+v", updated)
+				}
+			})
+		}
+	})
+}
+
+func TestRepositoryWriteCounts(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, reports Repository[models.Report], _ Repository[models.Vote]) {
+		ctx := context.Background()
+		seedReports(t, reports)
+
+		result, err := reports.UpdateMany(ctx, bson.M{"projectId": "p1"}, bson.M{"$set": bson.M{"resolved": true}})
+		if err != nil {
+			t.Fatalf("UpdateMany failed: %v", err)
+		}
+		if result.MatchedCount != 2 || result.ModifiedCount != 2 {
+			t.Errorf("matched %d and modified %d, want 2 and 2", result.MatchedCount, result.ModifiedCount)
+		}
+
+		// setting what is already there matches without modifying
+		result, err = reports.UpdateOne(ctx, bson.M{"title": "Slow search"}, bson.M{"$set": bson.M{"resolved": true}})
+		if err != nil {
+			t.Fatalf("UpdateOne failed: %v", err)
+		}
+		if result.MatchedCount != 1 || result.ModifiedCount != 0 {
+			t.Errorf("matched %d and modified %d, want 1 and 0", result.MatchedCount, result.ModifiedCount)
+		}
+
+		result, err = reports.UpdateOne(ctx, bson.M{"projectId": "p3"}, bson.M{"$set": bson.M{"resolved": true}})
+		if err != nil {
+			t.Fatalf("UpdateOne failed: %v", err)
+		}
+		if result.MatchedCount != 0 {
+			t.Errorf("matched %d, want 0", result.MatchedCount)
+		}
+
+		deleted, err := reports.DeleteMany(ctx, bson.M{"projectId": "p1"})
+		if err != nil {
+			t.Fatalf("DeleteMany failed: %v", err)
+		}
+		if deleted.DeletedCount != 2 {
+			t.Errorf("deleted %d, want 2", deleted.DeletedCount)
+		}
+
+		left, err := reports.Find(ctx, bson.M{})
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if got := titles(left); !slices.Equal(got, []string{"Upload crash"}) {
+			t.Errorf("left %v, want [Upload crash]", got)
+		}
+	})
+}
+
+func TestRepositoryErrors(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, reports Repository[models.Report], votes Repository[models.Vote]) {
+		ctx := context.Background()
+
+		if _, err := reports.FindOne(ctx, bson.M{"projectId": "missing"}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("FindOne with no match returned %v, want ErrNotFound", err)
+		}
+
+		vote := models.Vote{ID: primitive.NewObjectID(), UserID: "alice", ReportID: "r1", ProjectID: "p1"}
+		if _, err := votes.InsertOne(ctx, &vote); err != nil {
+			t.Fatalf("InsertOne failed: %v", err)
+		}
+
+		again := vote
+		again.ID = primitive.NewObjectID()
+		if _, err := votes.InsertOne(ctx, &again); !errors.Is(err, ErrDuplicateKey) {
+			t.Errorf("second vote by the same user returned %v, want ErrDuplicateKey", err)
+		}
+
+		if _, err := votes.InsertOne(ctx, &vote); !errors.Is(err, ErrDuplicateKey) {
+			t.Errorf("reusing an _id returned %v, want ErrDuplicateKey", err)
+		}
+
+		exists, err := votes.Exists(ctx, bson.M{"userId": "alice", "reportId": "r1"})
+		if err != nil || !exists {
+			t.Errorf("Exists returned %v, %v, want true", exists, err)
+		}
+	})
+}
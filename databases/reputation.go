@@ -0,0 +1,15 @@
+package databases
+
+import (
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+const reputationDBO = "reputation"
+
+type ReputationDatabase interface {
+	Repository[models.ReputationEvent]
+}
+
+func NewReputationDatabase(db DatabaseHelper) ReputationDatabase {
+	return NewRepository[models.ReputationEvent](db, reputationDBO)
+}
@@ -0,0 +1,585 @@
+package databases
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// sqlDialect holds what differs between the database/sql backends
+type sqlDialect interface {
+	driverName() string       // name the driver was registered with
+	migrationDir() string     // directory under migrations/ holding the schema
+	placeholder(n int) string // bind parameter for the nth (1-indexed) argument
+	lockRows() string         // clause appended to selects that are about to be updated
+	isUniqueViolation(err error) bool
+
+	// textSearch returns a condition matching rows whose text index fields
+	// contain any of the terms, along with the single argument it binds
+	textSearch(table string, fields []string, terms []string, n int) (string, any)
+
+	// fieldEquals returns a condition matching rows whose top level field is
+	// the string value or an array holding it, along with the arguments it binds
+	fieldEquals(field string, value string, n int) (string, []any)
+}
+
+// Collections in the SQL backends are tables with two columns, the portable
+// document id and the document itself as relaxed extended JSON. Lookups by
+// _id, $text searches and string equality on top level fields are pushed down
+// to the database so writes only lock rows that can match. The whole filter is
+// still evaluated in Go on the rows that come back
+
+type sqlClient struct {
+	dialect sqlDialect
+	db      *sql.DB
+}
+
+type sqlDatabase struct {
+	client *sqlClient
+}
+
+type sqlCollection struct {
+	client *sqlClient
+	table  string
+}
+
+type sqlSingleResult struct {
+	doc bson.M
+	err error
+}
+
+type sqlCursor struct {
+	docs []bson.M
+	err  error
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// sqlRow is a stored document together with its primary key
+type sqlRow struct {
+	id  string
+	doc bson.M
+}
+
+func newSQLClient(dialect sqlDialect, dsn string) (ClientHelper, error) {
+	db, err := sql.Open(dialect.driverName(), dsn)
+	return &sqlClient{dialect: dialect, db: db}, err
+}
+
+func (sc *sqlClient) Database(string) DatabaseHelper {
+	return &sqlDatabase{client: sc}
+}
+
+// Connect checks the database is reachable and applies any pending migrations
+func (sc *sqlClient) Connect() error {
+	ctx := context.Background()
+	if err := sc.db.PingContext(ctx); err != nil {
+		return err
+	}
+	return sc.migrate(ctx)
+}
+
+func (sd *sqlDatabase) Collection(name string) CollectionHelper {
+	return &sqlCollection{client: sd.client, table: name}
+}
+
+func (sd *sqlDatabase) Client() ClientHelper {
+	return sd.client
+}
+
+// EnsureIndexes does nothing as the migrations own the SQL schema
+func (sd *sqlDatabase) EnsureIndexes(context.Context) error {
+	return nil
+}
+
+// migrate runs every migration for the dialect that has not been applied yet
+func (sc *sqlClient) migrate(ctx context.Context) error {
+	_, err := sc.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version TEXT PRIMARY KEY)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	dir := path.Join("migrations", sc.dialect.migrationDir())
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		version := strings.TrimSuffix(entry.Name(), ".sql")
+
+		var applied int
+		row := sc.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations WHERE version = "+sc.dialect.placeholder(1), version)
+		if err := row.Scan(&applied); err != nil {
+			return err
+		}
+		if applied > 0 {
+			continue
+		}
+
+		script, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		err = sc.withTx(ctx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+				return err
+			}
+			_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES ("+sc.dialect.placeholder(1)+")", version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s failed: %w", version, err)
+		}
+	}
+	return nil
+}
+
+func (sc *sqlClient) withTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := sc.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// quotedTable returns the collection name quoted for use as an identifier
+func (sc *sqlCollection) quotedTable() string {
+	return `"` + strings.ReplaceAll(sc.table, `"`, `""`) + `"`
+}
+
+// scan returns every stored document matching filter ordered by id
+func (sc *sqlCollection) scan(ctx context.Context, q queryer, filter any, lock bool) ([]sqlRow, error) {
+	f, err := toDocument(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	d := sc.client.dialect
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if ids, ok := filterIDs(f); ok {
+		if len(ids) == 0 {
+			return nil, nil
+		}
+		placeholders := make([]string, len(ids))
+		for i, id := range ids {
+			args = append(args, id)
+			placeholders[i] = d.placeholder(len(args))
+		}
+		conditions = append(conditions, "id IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	if search, ok := f["$text"]; ok {
+		terms, err := searchTerms(search)
+		if err != nil {
+			return nil, err
+		}
+		if len(terms) == 0 {
+			return nil, nil
+		}
+
+		fields := textFields(sc.table)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("collection %s has no text index", sc.table)
+		}
+
+		clause, arg := d.textSearch(sc.table, fields, terms, len(args)+1)
+		conditions = append(conditions, clause)
+		args = append(args, arg)
+		delete(f, "$text")
+	}
+
+	for _, eq := range filterEqualities(f) {
+		clause, eqArgs := d.fieldEquals(eq.field, eq.value, len(args)+1)
+		conditions = append(conditions, clause)
+		args = append(args, eqArgs...)
+	}
+
+	query := "SELECT id, doc FROM " + sc.quotedTable()
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id"
+	if lock {
+		query += sc.client.dialect.lockRows()
+	}
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matched []sqlRow
+	for rows.Next() {
+		var (
+			id   string
+			data []byte
+		)
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, err
+		}
+
+		doc, err := decodeDocument(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s/%s: %w", sc.table, id, err)
+		}
+
+		ok, err := matchDocument(doc, f)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, sqlRow{id: id, doc: doc})
+		}
+	}
+	return matched, rows.Err()
+}
+
+// filterIDs extracts the ids a filter is restricted to when it has a top
+// level _id equality or $in, so the lookup can use the primary key
+func filterIDs(filter bson.M) ([]string, bool) {
+	cond, ok := filter["_id"]
+	if !ok {
+		return nil, false
+	}
+
+	values := primitive.A{cond}
+	if ops, ok := operatorDocument(cond); ok {
+		switch {
+		case len(ops) == 1 && ops["$eq"] != nil:
+			values = primitive.A{ops["$eq"]}
+		case len(ops) == 1 && ops["$in"] != nil:
+			values, ok = ops["$in"].(primitive.A)
+			if !ok {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+	}
+
+	ids := make([]string, 0, len(values))
+	for _, v := range values {
+		id, err := documentID(v)
+		if err != nil {
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+// fieldEquality is a top level field a filter requires to equal a string
+type fieldEquality struct {
+	field string
+	value string
+}
+
+// plainField matches field names that are safe to put in a JSON path
+var plainField = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// filterEqualities returns the top level string equalities of a filter in
+// field order. Dotted paths are left to the Go matcher as they can reach
+// into arrays of documents
+func filterEqualities(filter bson.M) []fieldEquality {
+	var out []fieldEquality
+	for field, cond := range filter {
+		if field == "_id" || !plainField.MatchString(field) {
+			continue
+		}
+
+		if ops, ok := operatorDocument(cond); ok {
+			if len(ops) != 1 {
+				continue
+			}
+			cond = ops["$eq"]
+		}
+
+		if value, ok := cond.(string); ok {
+			out = append(out, fieldEquality{field: field, value: value})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].field < out[j].field })
+	return out
+}
+
+// searchTerms splits a $text search into words, anything that is not a letter
+// or digit separates words so the terms are safe to hand to the search engine
+func searchTerms(search any) ([]string, error) {
+	ops, ok := search.(bson.M)
+	if !ok {
+		return nil, fmt.Errorf("$text needs a document")
+	}
+
+	text, ok := ops["$search"].(string)
+	if !ok {
+		return nil, fmt.Errorf("$text needs a $search string")
+	}
+
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), nil
+}
+
+// find returns the matching documents after sorting, paging and projection
+func (sc *sqlCollection) find(ctx context.Context, filter any, opts FindOptions) ([]bson.M, error) {
+	rows, err := sc.scan(ctx, sc.client.db, filter, false)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]bson.M, len(rows))
+	for i, row := range rows {
+		docs[i] = row.doc
+	}
+
+	sortSpec, err := toSort(opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+	sortDocuments(docs, sortSpec)
+
+	if opts.Skip > 0 {
+		if opts.Skip >= int64(len(docs)) {
+			docs = nil
+		} else {
+			docs = docs[opts.Skip:]
+		}
+	}
+	if opts.Limit > 0 && opts.Limit < int64(len(docs)) {
+		docs = docs[:opts.Limit]
+	}
+
+	if opts.Projection != nil {
+		projection, err := toDocument(opts.Projection)
+		if err != nil {
+			return nil, err
+		}
+		for i := range docs {
+			docs[i] = project(docs[i], projection)
+		}
+	}
+	return docs, nil
+}
+
+// toSort checks every sort direction is numeric
+func toSort(spec bson.D) (bson.D, error) {
+	out := make(bson.D, 0, len(spec))
+	for _, field := range spec {
+		if _, ok := toFloat(field.Value); !ok {
+			return nil, fmt.Errorf("sort direction for %s must be 1 or -1", field.Key)
+		}
+		out = append(out, field)
+	}
+	return out, nil
+}
+
+func (sc *sqlCollection) FindOne(ctx context.Context, filter any, opts FindOptions) SingleResultHelper {
+	opts.Limit = 1
+	docs, err := sc.find(ctx, filter, opts)
+	if err != nil {
+		return &sqlSingleResult{err: sc.sqlError(err)}
+	}
+	if len(docs) == 0 {
+		return &sqlSingleResult{err: ErrNotFound}
+	}
+	return &sqlSingleResult{doc: docs[0]}
+}
+
+func (sc *sqlCollection) Find(ctx context.Context, filter any, opts FindOptions) CursorHelper {
+	docs, err := sc.find(ctx, filter, opts)
+	return &sqlCursor{docs: docs, err: sc.sqlError(err)}
+}
+
+func (sc *sqlCollection) CountDocuments(ctx context.Context, filter any) (int64, error) {
+	rows, err := sc.scan(ctx, sc.client.db, filter, false)
+	if err != nil {
+		return 0, sc.sqlError(err)
+	}
+	return int64(len(rows)), nil
+}
+
+func (sc *sqlCollection) InsertOne(ctx context.Context, document any) (InsertOneResult, error) {
+	doc, err := toDocument(document)
+	if err != nil {
+		return InsertOneResult{}, err
+	}
+
+	if _, ok := doc["_id"]; !ok {
+		doc["_id"] = primitive.NewObjectID()
+	}
+
+	id, err := documentID(doc["_id"])
+	if err != nil {
+		return InsertOneResult{}, err
+	}
+
+	data, err := encodeDocument(doc)
+	if err != nil {
+		return InsertOneResult{}, err
+	}
+
+	d := sc.client.dialect
+	_, err = sc.client.db.ExecContext(ctx,
+		"INSERT INTO "+sc.quotedTable()+" (id, doc) VALUES ("+d.placeholder(1)+", "+d.placeholder(2)+")",
+		id, string(data),
+	)
+	if err != nil {
+		return InsertOneResult{}, sc.sqlError(err)
+	}
+	return InsertOneResult{InsertedID: doc["_id"]}, nil
+}
+
+func (sc *sqlCollection) UpdateOne(ctx context.Context, filter, update any) (UpdateResult, error) {
+	return sc.update(ctx, filter, update, false)
+}
+
+func (sc *sqlCollection) UpdateMany(ctx context.Context, filter, update any) (UpdateResult, error) {
+	return sc.update(ctx, filter, update, true)
+}
+
+func (sc *sqlCollection) update(ctx context.Context, filter, update any, many bool) (UpdateResult, error) {
+	u, err := toDocument(update)
+	if err != nil {
+		return UpdateResult{}, err
+	}
+
+	result := UpdateResult{}
+	err = sc.client.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := sc.scan(ctx, tx, filter, true)
+		if err != nil {
+			return err
+		}
+		if !many && len(rows) > 1 {
+			rows = rows[:1]
+		}
+
+		d := sc.client.dialect
+		for _, row := range rows {
+			result.MatchedCount++
+
+			updated := copyDocument(row.doc)
+			if err := applyUpdate(updated, u); err != nil {
+				return err
+			}
+			if compareDocuments(row.doc, updated) == 0 {
+				continue
+			}
+
+			data, err := encodeDocument(updated)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx,
+				"UPDATE "+sc.quotedTable()+" SET doc = "+d.placeholder(1)+" WHERE id = "+d.placeholder(2),
+				string(data), row.id,
+			)
+			if err != nil {
+				return err
+			}
+			result.ModifiedCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return UpdateResult{}, sc.sqlError(err)
+	}
+	return result, nil
+}
+
+func (sc *sqlCollection) DeleteOne(ctx context.Context, filter any) (DeleteResult, error) {
+	return sc.delete(ctx, filter, false)
+}
+
+func (sc *sqlCollection) DeleteMany(ctx context.Context, filter any) (DeleteResult, error) {
+	return sc.delete(ctx, filter, true)
+}
+
+func (sc *sqlCollection) delete(ctx context.Context, filter any, many bool) (DeleteResult, error) {
+	result := DeleteResult{}
+	err := sc.client.withTx(ctx, func(tx *sql.Tx) error {
+		rows, err := sc.scan(ctx, tx, filter, true)
+		if err != nil {
+			return err
+		}
+		if !many && len(rows) > 1 {
+			rows = rows[:1]
+		}
+
+		for _, row := range rows {
+			_, err := tx.ExecContext(ctx,
+				"DELETE FROM "+sc.quotedTable()+" WHERE id = "+sc.client.dialect.placeholder(1),
+				row.id,
+			)
+			if err != nil {
+				return err
+			}
+			result.DeletedCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return DeleteResult{}, sc.sqlError(err)
+	}
+	return result, nil
+}
+
+// sqlError maps driver errors onto the package level errors
+func (sc *sqlCollection) sqlError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if sc.client.dialect.isUniqueViolation(err) {
+		return errors.Join(ErrDuplicateKey, err)
+	}
+	return contextError(err)
+}
+
+func (sr *sqlSingleResult) Decode(v any) error {
+	if sr.err != nil {
+		return sr.err
+	}
+	return decodeInto(sr.doc, v)
+}
+
+func (cr *sqlCursor) Decode(v any) error {
+	if cr.err != nil {
+		return cr.err
+	}
+	return decodeAll(cr.docs, v)
+}
@@ -0,0 +1,71 @@
+package databases
+
+import (
+	"errors"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// defaultSQLiteFile is used when no DB_URI is set for the sqlite driver
+const defaultSQLiteFile = "bugbridge.db"
+
+// sqliteDialect stores collections in a single SQLite file using JSON text
+// documents, search is backed by FTS5 tables maintained by triggers
+type sqliteDialect struct{}
+
+func (sqliteDialect) driverName() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) migrationDir() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) placeholder(int) string {
+	return "?"
+}
+
+// lockRows is empty because write transactions begin immediately, which
+// already holds the database write lock
+func (sqliteDialect) lockRows() string {
+	return ""
+}
+
+func (sqliteDialect) isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	return sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+func (sqliteDialect) textSearch(table string, _ []string, terms []string, n int) (string, any) {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + term + `"`
+	}
+	return "id IN (SELECT id FROM " + table + "_fts WHERE " + table + "_fts MATCH ?)", strings.Join(quoted, " OR ")
+}
+
+// fieldEquals walks the field with json_each, which yields the elements of an
+// array and the value itself for anything else
+func (sqliteDialect) fieldEquals(field string, value string, _ int) (string, []any) {
+	return "EXISTS (SELECT 1 FROM json_each(doc, ?) WHERE json_each.value = ?)", []any{`$."` + field + `"`, value}
+}
+
+// sqliteDSN turns a file path into a DSN that enables WAL so readers never
+// block on the writer, waits on a busy database instead of failing and takes
+// the write lock at the start of every transaction
+func sqliteDSN(path string) string {
+	if path == "" {
+		path = defaultSQLiteFile
+	}
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + "_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)&_txlock=immediate"
+}
@@ -0,0 +1,15 @@
+package databases
+
+import (
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+const subscriptionDBO = "subscriptions"
+
+type SubscriptionDatabase interface {
+	Repository[models.Subscription]
+}
+
+func NewSubscriptionDatabase(db DatabaseHelper) SubscriptionDatabase {
+	return NewRepository[models.Subscription](db, subscriptionDBO)
+}
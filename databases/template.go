@@ -0,0 +1,23 @@
+package databases
+
+import (
+	"context"
+
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+const templateDBO = "templates"
+
+// TemplateDatabase can only add and read versions, a template version is never
+// changed. Versions are only deleted along with their project
+type TemplateDatabase interface {
+	FindOne(ctx context.Context, filter any, opts ...FindOptions) (*models.TemplateVersion, error)
+	Find(ctx context.Context, filter any, opts ...FindOptions) ([]models.TemplateVersion, error)
+	FindPage(ctx context.Context, filter any, page Page, opts ...FindOptions) (*PageResult[models.TemplateVersion], error)
+	InsertOne(ctx context.Context, document *models.TemplateVersion) (*InsertOneResult, error)
+	DeleteMany(ctx context.Context, filter any) (*DeleteResult, error)
+}
+
+func NewTemplateDatabase(db DatabaseHelper) TemplateDatabase {
+	return NewRepository[models.TemplateVersion](db, templateDBO)
+}
@@ -0,0 +1,159 @@
+package databases
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// applyUpdate applies a MongoDB style update document to doc in place
+func applyUpdate(doc bson.M, update bson.M) error {
+	if len(update) == 0 {
+		return fmt.Errorf("update document must not be empty")
+	}
+
+	for op, arg := range update {
+		fields, ok := arg.(bson.M)
+		if !ok {
+			return fmt.Errorf("update operator %s needs a document", op)
+		}
+
+		for path, value := range fields {
+			if path == "_id" || strings.HasPrefix(path, "_id.") {
+				return fmt.Errorf("the _id field cannot be updated")
+			}
+
+			var err error
+			switch op {
+			case "$set":
+				err = setPath(doc, path, value)
+			case "$setOnInsert":
+				// we never upsert so there is nothing to insert
+			case "$unset":
+				unsetPath(doc, path)
+			case "$inc":
+				err = incPath(doc, path, value)
+			case "$push":
+				err = pushPath(doc, path, value, false)
+			case "$addToSet":
+				err = pushPath(doc, path, value, true)
+			case "$pull":
+				err = pullPath(doc, path, value)
+			default:
+				if !strings.HasPrefix(op, "$") {
+					return fmt.Errorf("update document must only contain operators, got %s", op)
+				}
+				return fmt.Errorf("unsupported update operator %s", op)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func incPath(doc bson.M, path string, delta any) error {
+	by, ok := toFloat(delta)
+	if !ok {
+		return fmt.Errorf("cannot $inc %s by non-numeric value", path)
+	}
+
+	current, exists := getPath(doc, path)
+	if !exists || current == nil {
+		return setPath(doc, path, delta)
+	}
+
+	value, ok := toFloat(current)
+	if !ok {
+		return fmt.Errorf("cannot $inc non-numeric field %s", path)
+	}
+
+	_, currentIsFloat := current.(float64)
+	_, deltaIsFloat := delta.(float64)
+	if currentIsFloat || deltaIsFloat {
+		return setPath(doc, path, value+by)
+	}
+	return setPath(doc, path, int64(value+by))
+}
+
+// arrayAt returns the array stored at path, a missing field is an empty array
+func arrayAt(doc bson.M, path string) (primitive.A, error) {
+	current, exists := getPath(doc, path)
+	if !exists || current == nil {
+		return primitive.A{}, nil
+	}
+
+	arr, ok := current.(primitive.A)
+	if !ok {
+		return nil, fmt.Errorf("field %s is not an array", path)
+	}
+	return arr, nil
+}
+
+func pushPath(doc bson.M, path string, value any, unique bool) error {
+	arr, err := arrayAt(doc, path)
+	if err != nil {
+		return err
+	}
+
+	items := primitive.A{value}
+	if modifiers, ok := value.(bson.M); ok {
+		if each, ok := modifiers["$each"]; ok {
+			items, ok = each.(primitive.A)
+			if !ok {
+				return fmt.Errorf("$each needs an array")
+			}
+		}
+	}
+
+	for _, item := range items {
+		if unique && matchEqual([]any{arr}, item) {
+			continue
+		}
+		arr = append(arr, item)
+	}
+	return setPath(doc, path, arr)
+}
+
+func pullPath(doc bson.M, path string, cond any) error {
+	current, exists := getPath(doc, path)
+	if !exists || current == nil {
+		return nil
+	}
+
+	arr, ok := current.(primitive.A)
+	if !ok {
+		return fmt.Errorf("field %s is not an array", path)
+	}
+
+	_, isOperators := operatorDocument(cond)
+	condDoc, isDoc := cond.(bson.M)
+
+	kept := primitive.A{}
+	for _, elem := range arr {
+		var (
+			matched bool
+			err     error
+		)
+		elemDoc, elemIsDoc := elem.(bson.M)
+		switch {
+		case isDoc && !isOperators && elemIsDoc:
+			matched, err = matchDocument(elemDoc, condDoc)
+		case isOperators:
+			matched, err = matchField([]any{elem}, cond)
+		default:
+			matched = valuesEqual(elem, cond)
+		}
+		if err != nil {
+			return err
+		}
+		if !matched {
+			kept = append(kept, elem)
+		}
+	}
+	return setPath(doc, path, kept)
+}
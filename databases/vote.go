@@ -0,0 +1,15 @@
+package databases
+
+import (
+	"github.com/BugBridge/bugbridge-api/models"
+)
+
+const voteDBO = "votes"
+
+type VoteDatabase interface {
+	Repository[models.Vote]
+}
+
+func NewVoteDatabase(db DatabaseHelper) VoteDatabase {
+	return NewRepository[models.Vote](db, voteDBO)
+}
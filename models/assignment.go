@@ -0,0 +1,24 @@
+package models
+
+// Auto-assignment strategies a project can choose from
+const (
+	AutoAssignRoundRobin = "round_robin" // cycle through the project owner and admins
+	AutoAssignByLabel    = "label"       // assign the users of the first rule matching a report label
+)
+
+// AutoAssign decides who new reports of a project are assigned to
+type AutoAssign struct {
+	Strategy     string      `json:"strategy"               bson:"strategy"               validate:"required,oneof=round_robin label"`
+	Rules        []LabelRule `json:"rules,omitempty"        bson:"rules,omitempty"        validate:"required_if=Strategy label,dive"` // Rules used by the label strategy, first match wins
+	LastAssignee string      `json:"-"                      bson:"lastAssignee,omitempty"`                                            // Who the round robin strategy assigned last
+}
+
+type LabelRule struct {
+	Label       string   `json:"label"       bson:"label"       validate:"required,max=30"`
+	AssigneeIDs []string `json:"assigneeIds" bson:"assigneeIds" validate:"required,min=1,max=10,dive,required"`
+}
+
+// Data structure of the json object received in POST to assign users to a report
+type AssignDetails struct {
+	UserIDs []string `json:"userIds" validate:"required,min=1,max=10,dive,required"`
+}
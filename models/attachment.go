@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attachment is a file uploaded to a report or one of its comments, its contents
+// are kept in the blob store under the attachment's id
+type Attachment struct {
+	ID          primitive.ObjectID `json:"_id"                 bson:"_id"`                 // Id of the attachment and key of its contents
+	ProjectID   string             `json:"projectId"           bson:"projectId"`           // Project the attachment counts against the quota of
+	ReportID    string             `json:"reportId"            bson:"reportId"`            // Report the attachment belongs to
+	CommentID   string             `json:"commentId,omitempty" bson:"commentId,omitempty"` // Comment the attachment belongs to, unset for report attachments
+	UploaderID  string             `json:"uploaderId"          bson:"uploaderId"`          // Id of who uploaded the file
+	Filename    string             `json:"filename"            bson:"filename"`            // Name of the file as uploaded
+	ContentType string             `json:"contentType"         bson:"contentType"`         // MIME type sniffed from the contents
+	Size        int64              `json:"size"                bson:"size"`                // Size of the file in bytes
+	CreatedAt   time.Time          `json:"createdAt"           bson:"createdAt"`           // When the file was uploaded
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// States a bounty can be in
+const (
+	BountyPending = "pending" // awarded but not paid out yet
+	BountyPaid    = "paid"
+)
+
+// Kinds of entries in the reward ledger
+const (
+	LedgerAwarded = "awarded"
+	LedgerPaid    = "paid"
+)
+
+// RewardTier is the range a project pays for reports of a severity.
+// Amounts are in the smallest unit of the currency, cents for USD
+type RewardTier struct {
+	Severity string `json:"severity" bson:"severity" validate:"required,oneof=none low medium high critical"`
+	Min      int64  `json:"min"      bson:"min"      validate:"min=0"`
+	Max      int64  `json:"max"      bson:"max"      validate:"gtefield=Min"`
+}
+
+// RewardTable is what a project pays researchers for valid findings
+type RewardTable struct {
+	Currency string       `json:"currency" bson:"currency" validate:"required,iso4217"` // Currency every tier is paid in e.g. "USD"
+	Tiers    []RewardTier `json:"tiers"    bson:"tiers"    validate:"required,min=1,max=5,unique=Severity,dive"`
+}
+
+// Bounty is a reward awarded for a report, each report is awarded at most one.
+// Bounties outlive the reports they were awarded for
+type Bounty struct {
+	ID        primitive.ObjectID `json:"_id"              bson:"_id"`
+	ReportID  string             `json:"reportId"         bson:"reportId"`
+	ProjectID string             `json:"projectId"        bson:"projectId"`
+	UserID    string             `json:"userId"           bson:"userId"` // Researcher the bounty is paid to, the report's author
+	Severity  string             `json:"severity"         bson:"severity"`
+	Amount    int64              `json:"amount"           bson:"amount"` // In the smallest unit of Currency
+	Currency  string             `json:"currency"         bson:"currency"`
+	Status    string             `json:"status"           bson:"status"`
+	Note      string             `json:"note"             bson:"note"`
+	AwardedBy string             `json:"awardedBy"        bson:"awardedBy"`
+	AwardedAt time.Time          `json:"awardedAt"        bson:"awardedAt"`
+	PaidBy    string             `json:"paidBy,omitempty" bson:"paidBy,omitempty"`
+	PaidAt    *time.Time         `json:"paidAt,omitempty" bson:"paidAt,omitempty"`
+}
+
+// LedgerEntry records a bounty being awarded or paid. Entries are only ever
+// added, reward summaries are worked out from them
+type LedgerEntry struct {
+	ID        primitive.ObjectID `json:"_id"       bson:"_id"`
+	Type      string             `json:"type"      bson:"type"`
+	BountyID  string             `json:"bountyId"  bson:"bountyId"`
+	ReportID  string             `json:"reportId"  bson:"reportId"`
+	ProjectID string             `json:"projectId" bson:"projectId"`
+	UserID    string             `json:"userId"    bson:"userId"` // Researcher the bounty is paid to
+	Amount    int64              `json:"amount"    bson:"amount"`
+	Currency  string             `json:"currency"  bson:"currency"`
+	ActorID   string             `json:"actorId"   bson:"actorId"` // Admin who awarded or paid the bounty
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// Data structure of the json object received in POST to award a bounty
+type BountyDetails struct {
+	Amount   int64  `json:"amount"   validate:"required,min=1"`
+	Currency string `json:"currency" validate:"omitempty,iso4217"` // Defaults to the currency of the project's reward table
+	Note     string `json:"note"     validate:"max=500"`
+}
+
+// RewardSummary totals the bounties in one currency
+type RewardSummary struct {
+	Currency string `json:"currency"`
+	Bounties int64  `json:"bounties"` // Number of bounties awarded
+	Awarded  int64  `json:"awarded"`
+	Paid     int64  `json:"paid"`
+	Pending  int64  `json:"pending"` // Awarded but not paid out yet
+}
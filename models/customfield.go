@@ -0,0 +1,25 @@
+package models
+
+// Types a custom field can have
+const (
+	FieldString      = "string"
+	FieldNumber      = "number"
+	FieldEnum        = "enum"         // one of the field's options
+	FieldURL         = "url"          // an absolute http or https URL
+	FieldDate        = "date"         // a calendar date written as 2006-01-02
+	FieldMultiSelect = "multi_select" // any number of the field's options
+)
+
+// CustomField is a piece of metadata a project asks its reports for
+type CustomField struct {
+	Key      string   `json:"key"               bson:"key"               validate:"required,alphanum,max=30"`                                                               // Key the value is stored under in Report.Fields
+	Label    string   `json:"label"             bson:"label"             validate:"required,max=50"`                                                                        // Name shown next to the field e.g. "Browser"
+	Type     string   `json:"type"              bson:"type"              validate:"required,oneof=string number enum url date multi_select"`                                // Type values must have
+	Options  []string `json:"options,omitempty" bson:"options,omitempty" validate:"required_if=Type enum,required_if=Type multi_select,max=50,unique,dive,required,max=50"` // Choices of enum and multi_select fields
+	Required bool     `json:"required"          bson:"required"`                                                                                                            // Reports cannot be submitted without a value
+}
+
+// Data structure of the json object received in PUT to replace a project's custom fields
+type CustomFieldsDetails struct {
+	Fields []CustomField `json:"fields" validate:"max=30,unique=Key,dive"`
+}
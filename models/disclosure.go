@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Who can see a project, projects without a visibility are public
+const (
+	ProjectPublic     = "public"      // anyone can see the project and report to it
+	ProjectInviteOnly = "invite_only" // anyone can see the project, only members can report to it
+	ProjectPrivate    = "private"     // only members can see the project or report to it
+)
+
+// Who can read a report, reports without a visibility are private
+const (
+	ReportPrivate = "private" // the reporter, assignees and project admins
+	ReportPublic  = "public"  // anyone who can see the project, with the disclosure's redactions applied
+)
+
+// Redacted replaces redacted text in what the public sees of a disclosed report
+const Redacted = "[redacted]"
+
+// Disclosure is when a report is made public and what is kept out of it
+type Disclosure struct {
+	PublishOn   string     `json:"publishOn"             bson:"publishOn"`  // Day the report is made public, written as 2006-01-02
+	PublishAt   time.Time  `json:"publishAt"             bson:"publishAt"`  // Start of PublishOn in UTC
+	Redactions  []string   `json:"redactions,omitempty"  bson:"redactions"` // Text replaced with Redacted for readers other than the reporter, assignees and admins
+	ScheduledBy string     `json:"scheduledBy"           bson:"scheduledBy"`
+	ScheduledAt time.Time  `json:"scheduledAt"           bson:"scheduledAt"`
+	DisclosedAt *time.Time `json:"disclosedAt,omitempty" bson:"disclosedAt,omitempty"`
+}
+
+// Data structure of the json object received in POST to schedule a report's disclosure
+type DisclosureDetails struct {
+	PublishOn  string   `json:"publishOn"  validate:"required,datetime=2006-01-02"`
+	Redactions []string `json:"redactions" validate:"max=50,dive,required,max=500"`
+}
+
+// Data structure of the json object received in PUT to change who can see a project
+type ProjectVisibilityDetails struct {
+	Visibility string `json:"visibility" validate:"required,oneof=public invite_only private"`
+}
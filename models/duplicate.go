@@ -0,0 +1,21 @@
+package models
+
+// DuplicateCandidate is an open report that looks like the one being submitted
+type DuplicateCandidate struct {
+	ReportID string  `json:"reportId"`
+	Title    string  `json:"title"`
+	State    string  `json:"state"`
+	Score    float64 `json:"score"` // How alike the reports are, from 0 to 1
+}
+
+// Data structure of the json object received in POST to look for duplicates before submitting
+type DuplicateCheckDetails struct {
+	ProjectID string `json:"projectId" validate:"required"`
+	Title     string `json:"title"     validate:"required,max=50"`
+	Des       string `json:"des"       validate:"max=1000"`
+}
+
+// Data structure of the json object received in POST to merge a report into another
+type ReportMergeDetails struct {
+	Into string `json:"into" validate:"required"` // Id of the report that is kept
+}
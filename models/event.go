@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Types of events recorded for watchers
+const (
+	EventSLABreached     = "sla_breached"
+	EventReportDisclosed = "report_disclosed"
+)
+
+// Event is something that happened to a report or project, recorded once for
+// everyone watching it at the time
+type Event struct {
+	ID         primitive.ObjectID `json:"_id"                bson:"_id"`                // Id of event
+	Type       string             `json:"type"               bson:"type"`               // What happened e.g. "sla_breached"
+	ProjectID  string             `json:"projectId"          bson:"projectId"`          // Id of the project the event happened in
+	ReportID   string             `json:"reportId,omitempty" bson:"reportId,omitempty"` // Id of the report the event is about, empty for project events
+	ActorID    string             `json:"actorId,omitempty"  bson:"actorId,omitempty"`  // Id of the user who caused the event, empty for the system
+	Data       map[string]any     `json:"data"               bson:"data"`               // Details that depend on the type
+	Recipients []string           `json:"-"                  bson:"recipients"`         // Ids of the users the event is for
+	CreatedAt  time.Time          `json:"createdAt"          bson:"createdAt"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// States of an invitation, pending invitations past their expiry can no longer be answered
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+	InvitationRevoked  = "revoked"
+)
+
+// Invitation asks someone to join a project with a role. It is answered with
+// the token handed out when it was created, only its hash is stored
+type Invitation struct {
+	ID          primitive.ObjectID `json:"_id"                   bson:"_id"`                   // Id of invitation
+	ProjectID   string             `json:"projectId"             bson:"projectId"`             // Id of the project the invitation is to
+	Email       string             `json:"email,omitempty"       bson:"email,omitempty"`       // Address invited, set when the invitation was sent by email
+	UserID      string             `json:"userId,omitempty"      bson:"userId,omitempty"`      // Id of the user invited, set when the invitation was sent by username
+	Role        string             `json:"role"                  bson:"role"`                  // Role the user gets on accepting
+	TokenHash   string             `json:"-"                     bson:"tokenHash"`             // SHA-256 of the token, in hex
+	Status      string             `json:"status"                bson:"status"`                // One of the Invitation states
+	InvitedBy   string             `json:"invitedBy"             bson:"invitedBy"`             // Id of the admin who sent the invitation
+	CreatedAt   time.Time          `json:"createdAt"             bson:"createdAt"`             // When the invitation was sent
+	ExpiresAt   time.Time          `json:"expiresAt"             bson:"expiresAt"`             // When the invitation can no longer be accepted
+	RespondedAt *time.Time         `json:"respondedAt,omitempty" bson:"respondedAt,omitempty"` // When it was accepted, declined or revoked
+}
+
+// Data structure of the json object received in POST to invite someone to a project,
+// either an email or the username of an existing user is required
+type InvitationDetails struct {
+	Email    string `json:"email"    validate:"required_without=Username,excluded_with=Username,omitempty,email"`
+	Username string `json:"username" validate:"required_without=Email,omitempty,min=5,max=25"`
+	Role     string `json:"role"     validate:"required,oneof=admin member"`
+}
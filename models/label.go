@@ -0,0 +1,29 @@
+package models
+
+// Label is a project defined tag reports can be categorised with
+type Label struct {
+	Name        string `json:"name"        bson:"name"        validate:"required,max=30"` // Name of the label e.g. "ui"
+	Color       string `json:"color"       bson:"color"       validate:"required,hexcolor"`
+	Description string `json:"description" bson:"description" validate:"max=200"`
+}
+
+// Component is a part of a project a report can be filed against
+type Component struct {
+	Name        string `json:"name"        bson:"name"        validate:"required,max=30"` // Name of the component e.g. "login page"
+	Description string `json:"description" bson:"description" validate:"max=200"`
+}
+
+// Data structure of the json object received in PUT to replace a project's labels
+type LabelsDetails struct {
+	Labels []Label `json:"labels" validate:"max=100,unique=Name,dive"`
+}
+
+// Data structure of the json object received in PUT to replace a project's components
+type ComponentsDetails struct {
+	Components []Component `json:"components" validate:"max=100,unique=Name,dive"`
+}
+
+// Data structure of the json object received in POST to add labels or components to a report
+type ReportTagsDetails struct {
+	Names []string `json:"names" validate:"required,min=1,max=10,dive,required,max=30"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Types of links between reports, every link is stored on both reports with
+// the inverse type on the other one
+const (
+	LinkBlocks       = "blocks"
+	LinkBlockedBy    = "blocked_by"
+	LinkRelatesTo    = "relates_to"
+	LinkDuplicateOf  = "duplicate_of"
+	LinkDuplicatedBy = "duplicated_by"
+	LinkCausedBy     = "caused_by"
+	LinkCauses       = "causes"
+)
+
+// LinkInverses maps each link type onto the type stored on the linked report
+var LinkInverses = map[string]string{
+	LinkBlocks:       LinkBlockedBy,
+	LinkBlockedBy:    LinkBlocks,
+	LinkRelatesTo:    LinkRelatesTo,
+	LinkDuplicateOf:  LinkDuplicatedBy,
+	LinkDuplicatedBy: LinkDuplicateOf,
+	LinkCausedBy:     LinkCauses,
+	LinkCauses:       LinkCausedBy,
+}
+
+// ReportLink is a typed relationship from one report to another
+type ReportLink struct {
+	Type      string    `json:"type"      bson:"type"`      // How the report relates to the linked one e.g. "blocks"
+	ReportID  string    `json:"reportId"  bson:"reportId"`  // Id of the linked report
+	CreatedBy string    `json:"createdBy" bson:"createdBy"` // Id of who linked the reports
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// Data structure of the json object received in POST to link a report to another
+type ReportLinkDetails struct {
+	Type     string `json:"type"     validate:"required,oneof=blocks blocked_by relates_to duplicate_of duplicated_by caused_by causes"`
+	ReportID string `json:"reportId" validate:"required"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Roles a user can hold in a project
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+type Membership struct {
+	ID        primitive.ObjectID `json:"_id"       bson:"_id"`       // Id of membership
+	UserID    string             `json:"userId"    bson:"userId"`    // Id of the member
+	ProjectID string             `json:"projectId" bson:"projectId"` // Id of the project the user belongs to
+	Role      string             `json:"role"      bson:"role"`      // Role of the user in the project
+	JoinedAt  time.Time          `json:"joinedAt"  bson:"joinedAt"`  // When the user joined the project
+}
+
+// Data structure of the json object received in POST to add a member
+type MembershipDetails struct {
+	UserID string `json:"userId" validate:"required"`
+	Role   string `json:"role"   validate:"required,oneof=admin member"`
+}
+
+// Data structure of the json object received in PATCH to change a member's role
+type MembershipUpdateDetails struct {
+	Role string `json:"role" validate:"required,oneof=admin member"`
+}
+
+// MemberProject is a project listed for one of its members
+type MemberProject struct {
+	Project  Project   `json:"project"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+// ProjectMember is a user listed as a member of a project
+type ProjectMember struct {
+	User     User      `json:"user"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
@@ -0,0 +1,30 @@
+package models
+
+// States a milestone can be in
+const (
+	MilestoneOpen   = "open"
+	MilestoneClosed = "closed"
+)
+
+// Milestone is a release of a project, reports name milestones as the
+// versions they affect and the version they are fixed in
+type Milestone struct {
+	Name        string `json:"name"              bson:"name"              validate:"required,max=30"` // Name of the release e.g. "v1.2.0"
+	Description string `json:"description"       bson:"description"       validate:"max=500"`
+	DueDate     string `json:"dueDate,omitempty" bson:"dueDate,omitempty" validate:"omitempty,datetime=2006-01-02"` // When the release is due, written as 2006-01-02
+	State       string `json:"state"             bson:"state"             validate:"required,oneof=open closed"`
+}
+
+// Data structure of the json object received in PUT to replace a project's milestones
+type MilestonesDetails struct {
+	Milestones []Milestone `json:"milestones" validate:"max=100,unique=Name,dive"`
+}
+
+// MilestoneProgress counts the reports to be fixed in a milestone
+type MilestoneProgress struct {
+	Milestone Milestone `json:"milestone"`
+	Open      int64     `json:"open"`     // Reports with the milestone as fix version that are not resolved
+	Resolved  int64     `json:"resolved"` // Reports with the milestone as fix version that are resolved
+	Affected  int64     `json:"affected"` // Reports listing the milestone as an affected version
+	Percent   float64   `json:"percent"`  // Share of the fix version reports that are resolved, from 0 to 100
+}
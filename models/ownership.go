@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Types of changes recorded in a project's history
+const (
+	ProjectAdminAdded           = "admin_added"
+	ProjectAdminRemoved         = "admin_removed"
+	ProjectOwnershipTransferred = "ownership_transferred"
+	ProjectArchived             = "archived"
+	ProjectUnarchived           = "unarchived"
+)
+
+// ProjectChange is a change to who owns or administers a project, or to whether it is archived
+type ProjectChange struct {
+	Type   string    `json:"type"           bson:"type"`           // One of the project change types
+	UserID string    `json:"userId"         bson:"userId"`         // Id of the user the change is about, the owner for archiving
+	From   string    `json:"from,omitempty" bson:"from,omitempty"` // Id of the previous owner when ownership was transferred
+	By     string    `json:"by"             bson:"by"`             // Id of who made the change
+	At     time.Time `json:"at"             bson:"at"`             // When the change happened
+}
+
+// OwnershipTransfer is a transfer of a project's ownership waiting for the receiving user to accept it
+type OwnershipTransfer struct {
+	UserID      string    `json:"userId"      bson:"userId"`      // Id of the member receiving the project
+	RequestedBy string    `json:"requestedBy" bson:"requestedBy"` // Id of the owner who offered it
+	RequestedAt time.Time `json:"requestedAt" bson:"requestedAt"`
+}
+
+// Data structure of the json object received in POST to transfer a project's ownership
+type OwnershipTransferDetails struct {
+	UserID string `json:"userId" validate:"required"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Project struct {
 	ID        primitive.ObjectID `json:"_id"       bson:"_id"`      // ID of a project
@@ -9,6 +13,25 @@ type Project struct {
 	OwnerID   string             `json:"ownerId"   bson:"ownerId"`  // Owner ID of the project
 	AdminsIDs []string           `json:"adminIds"  bson:"adminIds"` // Array of IDs for users with admin privilages
 	Template  TemplateData       `json:"template"  bson:"template"` // Template that bug reports should be submitted
+	Workflow  *Workflow          `json:"workflow"  bson:"workflow"` // Workflow reports move through, nil uses the default
+
+	AutoAssign   *AutoAssign   `json:"autoAssign,omitempty" bson:"autoAssign,omitempty"` // How new reports are assigned, nil leaves them unassigned
+	Labels       []Label       `json:"labels"               bson:"labels"`               // Labels reports of the project can carry
+	Components   []Component   `json:"components"           bson:"components"`           // Components reports of the project can be filed against
+	CustomFields []CustomField `json:"customFields"         bson:"customFields"`         // Extra metadata reports of the project carry
+	Milestones   []Milestone   `json:"milestones"           bson:"milestones"`           // Releases reports are found in and fixed in
+	SLA          *SLAPolicy    `json:"sla,omitempty"        bson:"sla,omitempty"`        // Response times the project commits to, nil makes no commitment
+	Rewards      *RewardTable  `json:"rewards,omitempty"    bson:"rewards,omitempty"`    // What the project pays for reports by severity, nil when it runs no bounty program
+	StorageUsed  int64         `json:"storageUsed"          bson:"storageUsed"`          // Bytes of attachments stored, counted against the attachment quota
+	Visibility   string        `json:"visibility"           bson:"visibility"`           // Who can see the project and report to it, public when unset
+
+	Transfer *OwnershipTransfer `json:"transfer,omitempty" bson:"transfer,omitempty"` // Ownership transfer waiting to be accepted, nil when none is
+	History  []ProjectChange    `json:"history"            bson:"history"`            // Changes to who owns and administers the project and its archiving, oldest first
+
+	TemplateVersion int `json:"templateVersion" bson:"templateVersion"` // Version of Template in the templates collection, 0 until it is first recorded
+
+	Archived   bool       `json:"archived"             bson:"archived"`             // Archived projects are read only and left out of project listings
+	ArchivedAt *time.Time `json:"archivedAt,omitempty" bson:"archivedAt,omitempty"` // When the project was archived, unset while it isn't
 }
 
 // Data structure of the json object received in POST to create project
@@ -17,14 +40,26 @@ type ProjectDetails struct {
 	Des      string       `json:"des"       validate:"required,max=500"`
 	OwnerID  string       `json:"ownerId"   validate:"required"`
 	Template TemplateData `json:"template"  validate:"required"`
+
+	Visibility string `json:"visibility" validate:"omitempty,oneof=public invite_only private"` // Defaults to public
 }
 
 type TemplateData struct {
-	Title          string `json:"title"     bson:"title"     validate:"required,min=3,max=50"`
-	Des            string `json:"des"       bson:"des"       validate:"required,max=500"`
-	Steps          string `json:"steps"     bson:"steps"     validate:"required,max=1000"`
-	Behaviour      string `json:"behaviour" bson:"behaviour" validate:"required,max=1000"`
-	AdditionalInfo string `json:"addInfo"   bson:"addInfo"   validate:"max=1000"`
+	Title          string            `json:"title"     bson:"title"     validate:"required,min=3,max=50"`
+	Des            string            `json:"des"       bson:"des"       validate:"required,max=500"`
+	Steps          string            `json:"steps"     bson:"steps"     validate:"required,max=1000"`
+	Behaviour      string            `json:"behaviour" bson:"behaviour" validate:"required,max=1000"`
+	AdditionalInfo string            `json:"addInfo"   bson:"addInfo"   validate:"max=1000"`
+	Sections       []TemplateSection `json:"sections"  bson:"sections"  validate:"max=20,unique=Key,dive"` // Sections reports must fill in, empty uses steps, behaviour and addInfo
+}
+
+// TemplateSection is one part of a report the project's template asks for
+type TemplateSection struct {
+	Key       string `json:"key"       bson:"key"       validate:"required,alphanum,max=30"` // Key the section is stored under in Report.Sections
+	Label     string `json:"label"     bson:"label"     validate:"required,max=50"`          // Heading shown above the section
+	Prompt    string `json:"prompt"    bson:"prompt"    validate:"max=1000"`                 // What the reporter should write in the section
+	Required  bool   `json:"required"  bson:"required"`                                      // Reports cannot be submitted without it
+	MaxLength int    `json:"maxLength" bson:"maxLength" validate:"min=0,max=10000"`          // Longest the section can be, 0 uses the default
 }
 
 // Data structure of the json object received in PATCH to update project
@@ -35,9 +70,10 @@ type ProjectUpdateDetails struct {
 }
 
 type TemplateUpdateData struct {
-	Title          string `json:"title"     validate:"max=50"`
-	Des            string `json:"des"       validate:"max=500"`
-	Steps          string `json:"steps"     validate:"max=1000"`
-	Behaviour      string `json:"behaviour" validate:"max=1000"`
-	AdditionalInfo string `json:"addInfo"   validate:"max=1000"`
+	Title          string            `json:"title"     validate:"max=50"`
+	Des            string            `json:"des"       validate:"max=500"`
+	Steps          string            `json:"steps"     validate:"max=1000"`
+	Behaviour      string            `json:"behaviour" validate:"max=1000"`
+	AdditionalInfo string            `json:"addInfo"   validate:"max=1000"`
+	Sections       []TemplateSection `json:"sections"  validate:"omitempty,max=20,unique=Key,dive"`
 }
@@ -10,20 +10,61 @@ type Report struct {
 	Des       string             `json:"des"        bson:"des"`       // description of report
 	Severity  int                `json:"severity"   bson:"severity"`  // severity of report
 	Resolved  bool               `json:"resolved"   bson:"resolved"`  // array of IDs of solutions
+
+	State      string        `json:"state"                bson:"state"`                // Current workflow state of the report
+	Resolution string        `json:"resolution,omitempty" bson:"resolution,omitempty"` // Why the report reached a terminal state
+	History    []StateChange `json:"history"              bson:"history"`              // Every workflow transition, oldest first
+
+	Triage *Triage `json:"triage,omitempty" bson:"triage,omitempty"` // How the severity was decided, unset until triaged
+
+	AssigneeIDs []string `json:"assigneeIds" bson:"assigneeIds"` // Ids of the project members working on the report
+	Labels      []string `json:"labels"      bson:"labels"`      // Names of the project labels the report carries
+	Components  []string `json:"components"  bson:"components"`  // Names of the project components the report is filed against
+
+	Sections map[string]string `json:"sections" bson:"sections"` // Template sections keyed by TemplateSection.Key
+	Fields   map[string]any    `json:"fields"   bson:"fields"`   // Custom field values keyed by CustomField.Key
+
+	TemplateVersion int `json:"templateVersion" bson:"templateVersion"` // Version of the project template the sections were submitted against, 0 for reports from before versioning
+
+	DuplicateOf string       `json:"duplicateOf,omitempty" bson:"duplicateOf,omitempty"` // Id of the report this one was merged into
+	Links       []ReportLink `json:"links"                bson:"links"`                  // Typed relationships to other reports
+
+	Votes int `json:"votes" bson:"votes"` // Number of users the report affects, kept in step with the votes collection
+
+	AffectedVersions []string `json:"affectedVersions" bson:"affectedVersions"` // Names of the project milestones the bug was found in
+	FixVersion       string   `json:"fixVersion"       bson:"fixVersion"`       // Name of the project milestone the bug is fixed in, empty until planned
+
+	SLA *ReportSLA `json:"sla,omitempty" bson:"sla,omitempty"` // Deadlines under the project's SLA policy, unset when it has none
+
+	Visibility string      `json:"visibility"           bson:"visibility"`           // Who can read the report, private until it is disclosed
+	Disclosure *Disclosure `json:"disclosure,omitempty" bson:"disclosure,omitempty"` // When the report is or was made public, unset until an admin schedules it
 }
 
 // Data structure of the json object received in POST to create report
 type ReportDetails struct {
-	AuthorID  string `json:"authorId"  validate:"required"`          // ID of author
-	ProjectID string `json:"projectId" validate:"required"`          // Project ID report is submitted to
-	Title     string `json:"title"     validate:"required,max=50"`   // title of the report
-	Des       string `json:"des"       validate:"required,max=1000"` // description of report
+	AuthorID         string            `json:"authorId"   validate:"required"`                    // ID of author
+	ProjectID        string            `json:"projectId"  validate:"required"`                    // Project ID report is submitted to
+	Title            string            `json:"title"      validate:"required,max=50"`             // title of the report
+	Des              string            `json:"des"        validate:"required,max=1000"`           // description of report
+	Labels           []string          `json:"labels"     validate:"max=10,dive,required,max=30"` // names of the project labels the report carries
+	Components       []string          `json:"components" validate:"max=10,dive,required,max=30"` // names of the project components the report is filed against
+	Sections         map[string]string `json:"sections"   validate:"max=20"`                      // template sections keyed by TemplateSection.Key
+	Fields           map[string]any    `json:"fields"     validate:"max=30"`                      // custom field values keyed by CustomField.Key
+	IgnoreDuplicates bool              `json:"ignoreDuplicates"`                                  // submit even if the report looks like an open one
+
+	AffectedVersions []string `json:"affectedVersions" validate:"max=20,unique,dive,required,max=30"` // names of the project milestones the bug was found in
+	FixVersion       string   `json:"fixVersion"       validate:"max=30"`                             // name of the project milestone the bug will be fixed in
 }
 
 // Data structure of the json object received in PATCH to update report
 type ReportUpdateDetails struct {
-	AuthorID  string `json:"authorId"`                      // ID of author
-	ProjectID string `json:"projectId"`                     // Project ID report is submitted to
-	Title     string `json:"title"     validate:"max=50"`   // title of the report
-	Des       string `json:"des"       validate:"max=1000"` // description of report
+	AuthorID  string            `json:"authorId"`                      // ID of author
+	ProjectID string            `json:"projectId"`                     // Project ID report is submitted to
+	Title     string            `json:"title"     validate:"max=50"`   // title of the report
+	Des       string            `json:"des"       validate:"max=1000"` // description of report
+	Sections  map[string]string `json:"sections"  validate:"max=20"`   // template sections to change, merged into the current ones
+	Fields    map[string]any    `json:"fields"    validate:"max=30"`   // custom field values to change, merged into the current ones, null clears a value
+
+	AffectedVersions []string `json:"affectedVersions" validate:"omitempty,max=20,unique,dive,required,max=30"` // replaces the affected versions
+	FixVersion       *string  `json:"fixVersion"       validate:"omitempty,max=30"`                             // replaces the fix version, an empty string clears it
 }
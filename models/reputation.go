@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of reputation events a report can earn its author
+const (
+	ReputationValid     = "valid"     // the report was resolved as a real issue
+	ReputationDuplicate = "duplicate" // the report was closed as a duplicate
+	ReputationInvalid   = "invalid"   // the report was closed without being fixed
+	ReputationSeverity  = "severity"  // bonus for the severity of a valid report
+	ReputationBounty    = "bounty"    // a bounty was awarded for the report
+)
+
+// ReputationPoints is what each kind of event is worth, severity bonuses are in SeverityPoints
+var ReputationPoints = map[string]int{
+	ReputationValid:     10,
+	ReputationDuplicate: -2,
+	ReputationInvalid:   -5,
+	ReputationBounty:    25,
+}
+
+// SeverityPoints is the bonus a valid report earns for its severity
+var SeverityPoints = map[int]int{
+	SeverityLow:      5,
+	SeverityMedium:   10,
+	SeverityHigh:     20,
+	SeverityCritical: 40,
+}
+
+// ReputationEvent is points a report earned its author. A report earns each
+// kind of event at most once and a user's reputation is the sum of their events
+type ReputationEvent struct {
+	ID        primitive.ObjectID `json:"_id"       bson:"_id"`
+	UserID    string             `json:"userId"    bson:"userId"`
+	ProjectID string             `json:"projectId" bson:"projectId"`
+	ReportID  string             `json:"reportId"  bson:"reportId"`
+	Kind      string             `json:"kind"      bson:"kind"`
+	Points    int                `json:"points"    bson:"points"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// LeaderboardEntry is a user's standing on a leaderboard
+type LeaderboardEntry struct {
+	Rank   int  `json:"rank"`
+	User   User `json:"user"`
+	Points int  `json:"points"`
+}
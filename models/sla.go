@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Commitments an SLA policy can make about a report
+const (
+	SLAFirstResponse = "first_response" // a project member other than the reporter comments
+	SLATriage        = "triage"         // the report is given a severity
+	SLAResolve       = "resolve"        // the report reaches a terminal state
+)
+
+// SLAKinds lists the commitments in the order they fall due
+var SLAKinds = []string{SLAFirstResponse, SLATriage, SLAResolve}
+
+// States of a single SLA commitment
+const (
+	SLAPending  = "pending"  // not met yet and not due yet
+	SLAMet      = "met"      // met before it was due
+	SLALate     = "late"     // met after it was due
+	SLABreached = "breached" // due and still not met
+)
+
+// SLATarget sets how many hours a project has to meet each commitment for
+// reports of a severity, 0 makes no commitment
+type SLATarget struct {
+	Severity           string `json:"severity"           bson:"severity"           validate:"required,oneof=untriaged none low medium high critical"` // Severity level the target applies to, "untriaged" before triage
+	FirstResponseHours int    `json:"firstResponseHours" bson:"firstResponseHours" validate:"min=0,max=8760"`
+	TriageHours        int    `json:"triageHours"        bson:"triageHours"        validate:"min=0,max=8760"`
+	ResolveHours       int    `json:"resolveHours"       bson:"resolveHours"       validate:"min=0,max=8760"`
+}
+
+// SLAPolicy is the response times a project commits to, measured from when a report is submitted
+type SLAPolicy struct {
+	Targets []SLATarget `json:"targets" bson:"targets" validate:"required,min=1,max=6,unique=Severity,dive"`
+}
+
+// ReportSLA holds a report's deadlines under its project's SLA policy and
+// when they were met. Deadlines follow the report's severity
+type ReportSLA struct {
+	FirstResponseBy *time.Time `json:"firstResponseBy,omitempty" bson:"firstResponseBy,omitempty"`
+	TriageBy        *time.Time `json:"triageBy,omitempty"        bson:"triageBy,omitempty"`
+	ResolveBy       *time.Time `json:"resolveBy,omitempty"       bson:"resolveBy,omitempty"`
+
+	FirstResponseAt *time.Time `json:"firstResponseAt,omitempty" bson:"firstResponseAt,omitempty"`
+	TriagedAt       *time.Time `json:"triagedAt,omitempty"       bson:"triagedAt,omitempty"`
+	ResolvedAt      *time.Time `json:"resolvedAt,omitempty"      bson:"resolvedAt,omitempty"`
+
+	Breaches []string `json:"breaches" bson:"breaches"` // Commitments the SLA monitor has flagged as breached
+}
+
+// SLAStatus is where a single commitment of a report stands
+type SLAStatus struct {
+	Kind  string     `json:"kind"`
+	State string     `json:"state"`
+	Due   *time.Time `json:"due"`
+	MetAt *time.Time `json:"metAt,omitempty"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of things a user can watch
+const (
+	WatchReport  = "report"
+	WatchProject = "project"
+)
+
+// Why a user is watching, only manual subscriptions are created by the user
+const (
+	WatchManual    = "manual"
+	WatchAuthor    = "author"
+	WatchCommenter = "commenter"
+	WatchAssignee  = "assignee"
+)
+
+// Subscription records that a user wants to hear about changes to a report or project
+type Subscription struct {
+	ID         primitive.ObjectID `json:"_id"        bson:"_id"`        // Id of subscription
+	UserID     string             `json:"userId"     bson:"userId"`     // Id of the watching user
+	TargetType string             `json:"targetType" bson:"targetType"` // "report" or "project"
+	TargetID   string             `json:"targetId"   bson:"targetId"`   // Id of the watched report or project
+	ProjectID  string             `json:"projectId"  bson:"projectId"`  // Id of the project the target belongs to
+	Reason     string             `json:"reason"     bson:"reason"`     // Why the user is watching e.g. "author"
+	CreatedAt  time.Time          `json:"createdAt"  bson:"createdAt"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TemplateVersion is a project's template as it was between two changes, versions are never changed
+type TemplateVersion struct {
+	ID        primitive.ObjectID `json:"_id"       bson:"_id"`       // Id of template version
+	ProjectID string             `json:"projectId" bson:"projectId"` // Id of the project the template belongs to
+	Version   int                `json:"version"   bson:"version"`   // Numbered from 1 in the order the template changed
+	Template  TemplateData       `json:"template"  bson:"template"`  // Template as it was at this version
+	CreatedBy string             `json:"createdBy" bson:"createdBy"` // Id of who changed the template, empty for templates recorded after the fact
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// TemplateDiff is what changed in a project's template between two versions
+type TemplateDiff struct {
+	From     int                     `json:"from"`
+	To       int                     `json:"to"`
+	Fields   []TemplateFieldChange   `json:"fields"`   // Title, description and prompts that changed
+	Added    []TemplateSection       `json:"added"`    // Sections only in the later version
+	Removed  []TemplateSection       `json:"removed"`  // Sections only in the earlier version
+	Modified []TemplateSectionChange `json:"modified"` // Sections in both versions that changed
+}
+
+// TemplateFieldChange is a field of a template that changed between two versions
+type TemplateFieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// TemplateSectionChange is a section of a template that changed between two versions
+type TemplateSectionChange struct {
+	Key  string          `json:"key"`
+	From TemplateSection `json:"from"`
+	To   TemplateSection `json:"to"`
+}
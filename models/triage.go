@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Severity levels stored in Report.Severity
+const (
+	SeverityUntriaged = -1 // the report hasn't been assigned a severity level yet
+	SeverityNone      = 0
+	SeverityLow       = 1
+	SeverityMedium    = 2
+	SeverityHigh      = 3
+	SeverityCritical  = 4
+)
+
+// SeverityLevels maps the named severity levels onto Report.Severity
+var SeverityLevels = map[string]int{
+	"none":     SeverityNone,
+	"low":      SeverityLow,
+	"medium":   SeverityMedium,
+	"high":     SeverityHigh,
+	"critical": SeverityCritical,
+}
+
+// Triage records how a report's severity was decided
+type Triage struct {
+	Level     string    `json:"level"             bson:"level"`             // Named severity level e.g. "high"
+	Vector    string    `json:"vector,omitempty"  bson:"vector,omitempty"`  // CVSS vector the level was scored from
+	Version   string    `json:"version,omitempty" bson:"version,omitempty"` // CVSS version of the vector, "3.1" or "4.0"
+	Score     *float64  `json:"score,omitempty"   bson:"score,omitempty"`   // CVSS score computed from the vector
+	TriagedBy string    `json:"triagedBy"         bson:"triagedBy"`         // Id of the admin who triaged the report
+	TriagedAt time.Time `json:"triagedAt"         bson:"triagedAt"`         // When the report was triaged
+}
+
+// Data structure of the json object received in POST to triage a report,
+// exactly one of level or vector must be set
+type ReportTriageDetails struct {
+	Level  string `json:"level"  validate:"omitempty,oneof=none low medium high critical"`
+	Vector string `json:"vector" validate:"max=200"`
+}
@@ -8,6 +8,7 @@ type User struct {
 	Username   string             `json:"username"   bson:"username"`   // Username of user
 	Email      string             `json:"email"      bson:"email"`      // Email of user
 	Password   string             `json:"-"          bson:"password"`   // Password of user, it will not be sent over API?
+	Reputation int                `json:"reputation" bson:"reputation"` // Sum of the points the user's reports have earned
 }
 
 // Data structure of the json object received in POST to create user
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Vote is a user saying a report affects them too, users vote on a report at most once
+type Vote struct {
+	ID        primitive.ObjectID `json:"_id"       bson:"_id"`       // Id of vote
+	UserID    string             `json:"userId"    bson:"userId"`    // Id of the voting user
+	ReportID  string             `json:"reportId"  bson:"reportId"`  // Id of the report voted on
+	ProjectID string             `json:"projectId" bson:"projectId"` // Id of the project the report belongs to
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// Voter is a user listed as having voted on a report
+type Voter struct {
+	User    User      `json:"user"`
+	VotedAt time.Time `json:"votedAt"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Roles a transition can be restricted to, on top of the membership roles
+const (
+	RoleReporter = "reporter" // the author of the report
+)
+
+// Workflow is the set of states a project's reports move through
+type Workflow struct {
+	InitialState string               `json:"initialState" bson:"initialState" validate:"required"`            // State new reports start in
+	States       []WorkflowState      `json:"states"       bson:"states"       validate:"required,min=1,dive"` // Every state a report can be in
+	Transitions  []WorkflowTransition `json:"transitions"  bson:"transitions"  validate:"dive"`                // Allowed moves between states
+}
+
+type WorkflowState struct {
+	Name     string `json:"name"     bson:"name"     validate:"required,max=30"` // Name of the state e.g. "triaged"
+	Terminal bool   `json:"terminal" bson:"terminal"`                            // Reports in a terminal state are resolved and need a resolution reason
+}
+
+type WorkflowTransition struct {
+	From  []string `json:"from"  bson:"from"  validate:"required,min=1"`                                        // States the transition can start from
+	To    string   `json:"to"    bson:"to"    validate:"required"`                                              // State the transition ends in
+	Roles []string `json:"roles" bson:"roles" validate:"required,min=1,dive,oneof=owner admin member reporter"` // Roles allowed to perform the transition
+}
+
+// StateChange records a single transition of a report
+type StateChange struct {
+	From   string    `json:"from"             bson:"from"`             // State before the transition
+	To     string    `json:"to"               bson:"to"`               // State after the transition
+	UserID string    `json:"userId"           bson:"userId"`           // Id of who performed the transition
+	Reason string    `json:"reason,omitempty" bson:"reason,omitempty"` // Resolution reason or comment
+	At     time.Time `json:"at"               bson:"at"`               // When the transition happened
+}
+
+// Data structure of the json object received in POST to transition a report
+type ReportTransitionDetails struct {
+	To     string `json:"to"     validate:"required"`
+	Reason string `json:"reason" validate:"max=500"`
+}
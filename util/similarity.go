@@ -0,0 +1,56 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are left out of shingles, they appear in almost every report
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"but": true, "by": true, "for": true, "from": true, "has": true, "have": true, "i": true,
+	"in": true, "is": true, "it": true, "of": true, "on": true, "or": true, "that": true,
+	"the": true, "this": true, "to": true, "was": true, "when": true, "with": true,
+}
+
+// Words lowercases text and splits it into words, dropping punctuation and stop words
+func Words(text string) []string {
+	words := []string{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if !stopWords[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// Shingles returns the set of runs of up to size consecutive words in text,
+// so short texts still produce single word shingles
+func Shingles(text string, size int) map[string]bool {
+	words := Words(text)
+	shingles := map[string]bool{}
+	for n := 1; n <= size; n++ {
+		for i := 0; i+n <= len(words); i++ {
+			shingles[strings.Join(words[i:i+n], " ")] = true
+		}
+	}
+	return shingles
+}
+
+// Jaccard returns how much two shingle sets overlap, from 0 for nothing in
+// common to 1 for identical sets
+func Jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for shingle := range a {
+		if b[shingle] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
@@ -0,0 +1,94 @@
+package util
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"empty", "", []string{}},
+		{"only punctuation", "?!... --", []string{}},
+		{"only stop words", "the and of a", []string{}},
+		{"lowercased", "Login CRASHES", []string{"login", "crashes"}},
+		{"punctuation splits words", "login,crashes!app...v2", []string{"login", "crashes", "app", "v2"}},
+		{"stop words dropped", "the app crashes when I log in", []string{"app", "crashes", "log"}},
+		{"unicode letters kept", "Größe überschritten", []string{"größe", "überschritten"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Words(tc.text); !slices.Equal(got, tc.want) {
+				t.Errorf("Words(%q) = %q, want %q", tc.text, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestShingles(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		size int
+		want []string
+	}{
+		{"empty", "", 2, []string{}},
+		{"single word", "crash", 2, []string{"crash"}},
+		{"shorter than size", "login crash", 3, []string{"login", "crash", "login crash"}},
+		{"runs up to size", "login button crash", 2, []string{"login", "button", "crash", "login button", "button crash"}},
+		{"repeats counted once", "crash crash", 2, []string{"crash", "crash crash"}},
+		{"stop words skipped in runs", "login to the app", 2, []string{"login", "app", "login app"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := slices.Sorted(maps.Keys(Shingles(tc.text, tc.size)))
+			want := slices.Sorted(slices.Values(tc.want))
+			if !slices.Equal(got, want) {
+				t.Errorf("Shingles(%q, %d) = %q, want %q", tc.text, tc.size, got, want)
+			}
+		})
+	}
+}
+
+func TestShinglesNormalise(t *testing.T) {
+	a := Shingles("The LOGIN button, crashes the app!", 2)
+	b := Shingles("login button crashes app", 2)
+	if !maps.Equal(a, b) {
+		t.Errorf("case, punctuation and stop words changed the shingles: %v and %v", a, b)
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{"identical", "login button crashes", "login button crashes", 1},
+		{"identical after normalising", "Login button: crashes!", "login BUTTON crashes", 1},
+		{"disjoint", "login button crashes", "slow search results", 0},
+		{"both empty", "", "", 0},
+		{"one empty", "login crashes", "", 0},
+		{"only stop words", "the and of", "the and of", 0},
+		{"partial overlap", "login crashes", "login hangs", 1.0 / 5},
+		{"subset", "login button crashes app", "login button crashes app login again", 7.0 / 10},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := Shingles(tc.a, 2), Shingles(tc.b, 2)
+			if got := Jaccard(a, b); got != tc.want {
+				t.Errorf("Jaccard(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+			}
+			if got := Jaccard(b, a); got != tc.want {
+				t.Errorf("Jaccard is not symmetric for %q and %q, got %v", tc.a, tc.b, got)
+			}
+		})
+	}
+}